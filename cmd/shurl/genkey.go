@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pauloo27/shurl/internal/apikey"
+)
+
+// genKey prints a new API key and the hash to put in the config instead of
+// it (apiKeyHash).
+func genKey() {
+	key, err := apikey.Generate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to generate api key:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("API key:    ", key)
+	fmt.Println("apiKeyHash: ", apikey.Hash(key))
+}
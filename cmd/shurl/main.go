@@ -13,6 +13,11 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "genkey" {
+		genKey()
+		return
+	}
+
 	cfg, err := config.LoadConfigFromFile(DefaultConfigPath)
 	if err != nil {
 		slog.Error("Failed to load config:", "err", err)
@@ -0,0 +1,37 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+const (
+	generatedLength = 32
+)
+
+// Generate creates a random API key.
+func Generate() (string, error) {
+	return gonanoid.New(generatedLength)
+}
+
+// Hash is the digest of the API key that is stored instead of it (hex encoded
+// SHA-256). API keys are random enough to not need a salt, which allows
+// looking apps up by it.
+func Hash(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
+
+// ValidHash tells if the hash looks like one returned by Hash.
+func ValidHash(hash string) bool {
+	decoded, err := hex.DecodeString(hash)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+// Matches compares the key against the hash in constant time.
+func Matches(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hash)) == 1
+}
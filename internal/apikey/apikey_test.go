@@ -0,0 +1,34 @@
+package apikey_test
+
+import (
+	"testing"
+
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	hash := apikey.Hash("key")
+	assert.Equal(t, "2c70e12b7a0646f92279f427c7b38e7334d8e5389cff167a1dc30e73f826b683", hash)
+	assert.True(t, apikey.ValidHash(hash))
+	assert.True(t, apikey.Matches("key", hash))
+	assert.False(t, apikey.Matches("other", hash))
+	assert.False(t, apikey.Matches("key", ""))
+}
+
+func TestValidHash(t *testing.T) {
+	assert.False(t, apikey.ValidHash(""))
+	assert.False(t, apikey.ValidHash("key"))
+	assert.False(t, apikey.ValidHash("zz70e12b7a0646f92279f427c7b38e7334d8e5389cff167a1dc30e73f826b683"))
+	assert.False(t, apikey.ValidHash("2c70e12b7a0646f92279f427c7b38e73"))
+}
+
+func TestGenerate(t *testing.T) {
+	a, err := apikey.Generate()
+	assert.NoError(t, err)
+	b, err := apikey.Generate()
+	assert.NoError(t, err)
+
+	assert.Len(t, a, 32)
+	assert.NotEqual(t, a, b)
+}
@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/pauloo27/shurl/internal/providers"
 	"github.com/pauloo27/shurl/internal/providers/valkey"
 	"github.com/pauloo27/shurl/internal/server"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
 )
 
 func Start(cfg *config.Config) {
@@ -22,9 +24,17 @@ func Start(cfg *config.Config) {
 		os.Exit(1)
 	}
 
+	registry := apps.NewRegistry(cfg, vkey)
+	if err := registry.Load(context.Background()); err != nil {
+		slog.Error("Failed to load apps:", "err", err)
+		os.Exit(1)
+	}
+	go registry.Watch(context.Background())
+
 	providers := &providers.Providers{
 		Config: cfg,
 		Valkey: vkey,
+		Apps:   registry,
 	}
 
 	err = server.StartServer(providers)
@@ -2,6 +2,9 @@ package config
 
 import (
 	"log/slog"
+	"time"
+
+	"github.com/pauloo27/shurl/internal/apikey"
 )
 
 type Config struct {
@@ -9,10 +12,22 @@ type Config struct {
 	HTTP   *HTTPConfig
 	Valkey *Valkey
 
+	Policy *PolicyConfig
+	Safety *SafetyConfig
+
+	Password *PasswordConfig
+	Reports  *ReportsConfig
+	Admin    *AdminConfig
+
+	Domains map[string]*DomainConfig
+
+	DomainByAlias map[string]string `yaml:"-" json:"-"`
+
 	Public *AppConfig
 
 	Apps map[string]*AppConfig
 
+	// keyed by the API key hash
 	AppByAPIKey map[string]*AppConfig `yaml:"-" json:"-"`
 }
 
@@ -31,7 +46,8 @@ type LogConfig struct {
 }
 
 type HTTPConfig struct {
-	Port int
+	Port           int
+	TrustedProxies []string
 }
 
 type Valkey struct {
@@ -40,11 +56,143 @@ type Valkey struct {
 	DB       int
 }
 
+const (
+	PublicAppName = "public"
+	// label of the apiKey (or apiKeyHash) of the app
+	DefaultAPIKeyLabel = "default"
+)
+
 type AppConfig struct {
-	Enabled        bool
-	APIKey         string
-	MinDurationSec int
-	MaxDurationSec int
+	Name                string `yaml:"-" json:"-"`
+	Enabled             bool
+	APIKey              string
+	APIKeyHash          string
+	APIKeys             []APIKeyConfig
+	MinDurationSec      int
+	MaxDurationSec      int
+	RedirectTypes       []int
+	DefaultRedirectType int
+	AllowedSchemes      []string
+	Domains             []string
+	Normalize           *NormalizeConfig
+	Policy              *PolicyConfig
+	UTM                 *UTMConfig
+	ExpiredGraceSec     int
+	FallbackURL         string
+	Interstitial        bool
 	//LimitPerIPPerHour int TODO:
 	//AllowCustomSlug bool TODO:
 }
+
+// APIKeyDigest is the hash of the app API key, the one in the config or the
+// hash of the plain one. Empty when the app has no API key.
+func (c *AppConfig) APIKeyDigest() string {
+	return apiKeyDigest(c.APIKey, c.APIKeyHash)
+}
+
+// Keys returns every API key of the app, the apiKey (or apiKeyHash) one
+// included, labeled as DefaultAPIKeyLabel.
+func (c *AppConfig) Keys() []APIKeyConfig {
+	keys := make([]APIKeyConfig, 0, len(c.APIKeys)+1)
+
+	if digest := c.APIKeyDigest(); digest != "" {
+		keys = append(keys, APIKeyConfig{Label: DefaultAPIKeyLabel, KeyHash: digest})
+	}
+
+	return append(keys, c.APIKeys...)
+}
+
+// APIKeyConfig is one of the keys of an app, apps may have many so they can
+// be rotated without downtime.
+type APIKeyConfig struct {
+	Label    string
+	Key      string
+	KeyHash  string
+	NotAfter *time.Time
+}
+
+func (k *APIKeyConfig) Digest() string {
+	return apiKeyDigest(k.Key, k.KeyHash)
+}
+
+// Active tells if the key can still be used.
+func (k *APIKeyConfig) Active(now time.Time) bool {
+	return k.NotAfter == nil || now.Before(*k.NotAfter)
+}
+
+func apiKeyDigest(key, hash string) string {
+	if hash != "" {
+		return hash
+	}
+	if key == "" {
+		return ""
+	}
+	return apikey.Hash(key)
+}
+
+type NormalizeConfig struct {
+	Enabled             bool
+	SortQuery           bool
+	StripTrackingParams bool
+}
+
+type PolicyConfig struct {
+	Allow DomainRules
+	Block DomainRules
+	File  string
+}
+
+type DomainRules struct {
+	Exact  []string
+	Suffix []string
+	Regex  []string
+}
+
+type SafetyConfig struct {
+	BlockPrivateNetworks bool
+	AllowChaining        bool
+	MaxChainDepth        int
+	Suspicious           *PolicyConfig
+}
+
+type PasswordConfig struct {
+	CookieSecret     string
+	CookieTTLSec     int
+	MaxFailures      int
+	FailureWindowSec int
+}
+
+type ReportsConfig struct {
+	QuarantineThreshold int
+	MaxPerIP            int
+	WindowSec           int
+}
+
+type AdminConfig struct {
+	APIKey     string
+	APIKeyHash string
+}
+
+// APIKeyDigest is the hash of the admin API key, empty when there's none.
+func (c *AdminConfig) APIKeyDigest() string {
+	return apiKeyDigest(c.APIKey, c.APIKeyHash)
+}
+
+type DomainConfig struct {
+	Aliases       []string
+	Scheme        string
+	DefaultApp    string
+	ReservedSlugs []string
+	NotFoundURL   string
+	NotActiveURL  string
+	NotFoundPage  string
+	GonePage      string
+}
+
+type UTMConfig struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
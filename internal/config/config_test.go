@@ -3,8 +3,11 @@ package config_test
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pauloo27/shurl/internal/apikey"
 	"github.com/pauloo27/shurl/internal/config"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +18,25 @@ const (
 
 var (
 	mustBeUnset = map[string]bool{
-		"Config.Public.APIKey": true,
+		"Config.Public.APIKey":     true,
+		"Config.Public.APIKeyHash": true,
+		// the example uses plain keys, so they can be tried out
+		"Config.Apps[testing].APIKeyHash":         true,
+		"Config.Admin.APIKeyHash":                 true,
+		"Config.Apps[testing].APIKeys[0].KeyHash": true,
+		// secrets must not be copied from the example
+		"Config.Password.CookieSecret": true,
+		"Config.Admin.APIKey":          true,
+		// optional policy files
+		"Config.Policy.File":               true,
+		"Config.Public.Policy.File":        true,
+		"Config.Apps[testing].Policy.File": true,
+		"Config.Safety.Suspicious.File":    true,
+		// optional default app
+		"Config.Domains[localhost].DefaultApp": true,
+		// optional custom error pages
+		"Config.Domains[localhost].NotFoundPage": true,
+		"Config.Domains[localhost].GonePage":     true,
 	}
 )
 
@@ -51,6 +72,182 @@ func TestLoadConfigWithPublicAPIKey(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestLoadConfigWithInvalidPolicyRegex(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("policy: { block: { regex: ['('] } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithInvalidSuspiciousRegex(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("safety: { suspicious: { block: { regex: ['('] } } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithAPIKeyHash(t *testing.T) {
+	hash := apikey.Hash("key")
+
+	cfg, err := config.LoadConfigFromData([]byte("apps: { team: { apiKeyHash: '" + hash + "' }, keyless: {} }"))
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Apps["team"], cfg.AppByAPIKey[hash])
+	assert.Len(t, cfg.AppByAPIKey, 1)
+
+	cfg, err = config.LoadConfigFromData([]byte("apps: { team: { apiKey: 'key' } }"))
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Apps["team"], cfg.AppByAPIKey[hash])
+}
+
+func TestLoadConfigWithUppercaseAPIKeyHash(t *testing.T) {
+	upper := func(key string) string {
+		return strings.ToUpper(apikey.Hash(key))
+	}
+
+	cfg, err := config.LoadConfigFromData([]byte(`
+admin: { apiKeyHash: '` + upper("admin") + `' }
+apps:
+  team:
+    apiKeyHash: '` + upper("old") + `'
+    apiKeys:
+      - { label: 'new', keyHash: '` + upper("new") + `' }
+`))
+	assert.NoError(t, err)
+
+	team := cfg.Apps["team"]
+	for _, key := range []string{"old", "new"} {
+		assert.Equal(t, team, cfg.AppByAPIKey[apikey.Hash(key)], key)
+	}
+	assert.Equal(t, apikey.Hash("admin"), cfg.Admin.APIKeyDigest())
+}
+
+func TestLoadConfigWithAPIKeys(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte(`
+apps:
+  team:
+    apiKey: 'old'
+    apiKeys:
+      - { label: 'new', key: 'new' }
+      - { label: 'hashed', keyHash: '` + apikey.Hash("hashed") + `', notAfter: '2030-01-01T00:00:00Z' }
+`))
+	assert.NoError(t, err)
+
+	team := cfg.Apps["team"]
+	for _, key := range []string{"old", "new", "hashed"} {
+		assert.Equal(t, team, cfg.AppByAPIKey[apikey.Hash(key)], key)
+	}
+
+	keys := team.Keys()
+	assert.Len(t, keys, 3)
+	assert.Equal(t, config.DefaultAPIKeyLabel, keys[0].Label)
+	assert.Equal(t, "new", keys[1].Label)
+	assert.Equal(t, "hashed", keys[2].Label)
+	assert.True(t, keys[2].Active(time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, keys[2].Active(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestLoadConfigWithInvalidAPIKeys(t *testing.T) {
+	cases := map[string]string{
+		"no key":            "apps: { team: { apiKeys: [{ label: 'a' }] } }",
+		"no label":          "apps: { team: { apiKeys: [{ key: 'a' }] } }",
+		"key and hash":      "apps: { team: { apiKeys: [{ label: 'a', key: 'a', keyHash: '" + apikey.Hash("a") + "' }] } }",
+		"invalid hash":      "apps: { team: { apiKeys: [{ label: 'a', keyHash: 'nope' }] } }",
+		"duplicated label":  "apps: { team: { apiKeys: [{ label: 'a', key: 'a' }, { label: 'a', key: 'b' }] } }",
+		"default label":     "apps: { team: { apiKey: 'a', apiKeys: [{ label: 'default', key: 'b' }] } }",
+		"same key twice":    "apps: { team: { apiKey: 'a', apiKeys: [{ label: 'b', key: 'a' }] } }",
+		"key of other app":  "apps: { a: { apiKey: 'a' }, b: { apiKeys: [{ label: 'b', key: 'a' }] } }",
+		"public with a key": "public: { apiKeys: [{ label: 'a', key: 'a' }] }",
+	}
+
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.LoadConfigFromData([]byte(data))
+			assert.Nil(t, cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigWithInvalidAPIKeyHash(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("apps: { team: { apiKeyHash: 'nope' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+
+	cfg, err = config.LoadConfigFromData([]byte("admin: { apiKeyHash: 'nope' }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithAPIKeyAndHash(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("apps: { team: { apiKey: 'key', apiKeyHash: '" + apikey.Hash("key") + "' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithDuplicatedAPIKey(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("apps: { a: { apiKey: 'key' }, b: { apiKeyHash: '" + apikey.Hash("key") + "' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithAdminKeyHashUsedByApp(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("admin: { apiKeyHash: '" + apikey.Hash("key") + "' }\napps: { team: { apiKey: 'key' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithAdminKeyUsedByApp(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("admin: { apiKey: 'key' }\napps: { team: { apiKey: 'key' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithUnknownDefaultApp(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("domains: { sh.example.com: { defaultApp: 'nope' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithInvalidDomainScheme(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("domains: { sh.example.com: { scheme: 'ftp' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithDuplicatedAlias(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("domains: { a.example.com: { aliases: ['s.example.com'] }, b.example.com: { aliases: ['s.example.com'] } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithAliasAsDomain(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("domains: { a.example.com: { aliases: ['b.example.com'] }, b.example.com: {} }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithAliases(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("domains: { Sh.Example.com: { aliases: ['S.example.com'] } }"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"s.example.com": "sh.example.com"}, cfg.DomainByAlias)
+}
+
+func TestLoadConfigWithInvalidRedirectType(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("apps: { app: { redirectTypes: [200] } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithDefaultRedirectTypeNotAllowed(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("public: { redirectTypes: [302], defaultRedirectType: 307 }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigWithReservedAppName(t *testing.T) {
+	cfg, err := config.LoadConfigFromData([]byte("apps: { public: { apiKey: 'key' } }"))
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+}
+
 func TestLoadDefaultConfig(t *testing.T) {
 	cfg, err := config.LoadConfigFromFile(defaultConfigPath)
 	assert.NoError(t, err)
@@ -92,6 +289,11 @@ func check(t *testing.T, val any, path string) {
 		valType = reflect.TypeOf(val)
 	}
 
+	if _, isTime := val.(time.Time); isTime {
+		assert.NotZerof(t, val, "Value for %s must NOT be zero, but was", path)
+		return
+	}
+
 	switch valType.Kind() {
 	case reflect.String, reflect.Int, reflect.Bool:
 		if mustBeUnset[path] {
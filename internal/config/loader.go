@@ -2,9 +2,25 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/ghodss/yaml"
+	"github.com/pauloo27/shurl/internal/apikey"
+)
+
+var (
+	validRedirectTypes = map[int]bool{
+		http.StatusMovedPermanently:  true,
+		http.StatusFound:             true,
+		http.StatusSeeOther:          true,
+		http.StatusTemporaryRedirect: true,
+		http.StatusPermanentRedirect: true,
+	}
 )
 
 func LoadConfigFromFile(configPath string) (*Config, error) {
@@ -27,19 +43,190 @@ func LoadConfigFromData(data []byte) (*Config, error) {
 
 	ensureNotNil(&config)
 
+	// the hashes are compared to the lowercase hex digests
+	config.Admin.APIKeyHash = strings.ToLower(config.Admin.APIKeyHash)
+	lowerKeyHashes(config.Public)
+	for _, app := range config.Apps {
+		lowerKeyHashes(app)
+	}
+
 	config.AppByAPIKey = make(map[string]*AppConfig)
 
-	if config.Public.APIKey != "" {
+	if len(config.Public.Keys()) > 0 {
 		return nil, errors.New("public client must not have api key")
 	}
 
-	for _, app := range config.Apps {
-		config.AppByAPIKey[app.APIKey] = app
+	if err := validateAPIKey("admin", config.Admin.APIKey, config.Admin.APIKeyHash); err != nil {
+		return nil, err
+	}
+	adminDigest := config.Admin.APIKeyDigest()
+
+	if err := validatePolicy(config.Policy); err != nil {
+		return nil, err
+	}
+
+	if err := validatePolicy(config.Safety.Suspicious); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateApp(PublicAppName, config.Public); err != nil {
+		return nil, err
+	}
+	config.Public.Name = PublicAppName
+
+	for name, app := range config.Apps {
+		if err := ValidateApp(name, app); err != nil {
+			return nil, err
+		}
+		if name == PublicAppName {
+			return nil, fmt.Errorf("app name %s is reserved", name)
+		}
+		app.Name = name
+
+		for _, key := range app.Keys() {
+			digest := key.Digest()
+			if adminDigest != "" && digest == adminDigest {
+				return nil, fmt.Errorf("app %s uses the admin api key", name)
+			}
+			if other, found := config.AppByAPIKey[digest]; found {
+				return nil, fmt.Errorf("apps %s and %s use the same api key", other.Name, name)
+			}
+			config.AppByAPIKey[digest] = app
+		}
+	}
+
+	domains := make(map[string]*DomainConfig, len(config.Domains))
+	for name, domain := range config.Domains {
+		if err := validateDomain(&config, name, domain); err != nil {
+			return nil, err
+		}
+		domains[strings.ToLower(name)] = domain
+	}
+	config.Domains = domains
+
+	config.DomainByAlias = make(map[string]string)
+	for name, domain := range config.Domains {
+		for _, alias := range domain.Aliases {
+			alias = strings.ToLower(alias)
+			if _, found := config.Domains[alias]; found {
+				return nil, fmt.Errorf("alias %s of domain %s is also a domain", alias, name)
+			}
+			if other, found := config.DomainByAlias[alias]; found {
+				return nil, fmt.Errorf("alias %s used by both %s and %s", alias, other, name)
+			}
+			config.DomainByAlias[alias] = name
+		}
 	}
 
 	return &config, nil
 }
 
+func validateDomain(cfg *Config, name string, domain *DomainConfig) error {
+	if domain == nil {
+		return fmt.Errorf("domain %s has no settings", name)
+	}
+
+	if domain.Scheme != "" && domain.Scheme != "http" && domain.Scheme != "https" {
+		return fmt.Errorf("domain %s has invalid scheme %q", name, domain.Scheme)
+	}
+
+	if domain.DefaultApp != "" && cfg.Apps[domain.DefaultApp] == nil {
+		return fmt.Errorf("domain %s default app %s not found", name, domain.DefaultApp)
+	}
+
+	return nil
+}
+
+// ValidateApp checks the settings of an app, from the config or created at
+// runtime.
+func ValidateApp(name string, app *AppConfig) error {
+	if app == nil {
+		return fmt.Errorf("app %s has no settings", name)
+	}
+
+	for _, redirectType := range app.RedirectTypes {
+		if !validRedirectTypes[redirectType] {
+			return fmt.Errorf("app %s has invalid redirect type %d", name, redirectType)
+		}
+	}
+
+	if app.DefaultRedirectType != 0 {
+		if !validRedirectTypes[app.DefaultRedirectType] {
+			return fmt.Errorf("app %s has invalid default redirect type %d", name, app.DefaultRedirectType)
+		}
+
+		if len(app.RedirectTypes) > 0 && !slices.Contains(app.RedirectTypes, app.DefaultRedirectType) {
+			return fmt.Errorf("app %s default redirect type is not allowed", name)
+		}
+	}
+
+	if err := validateAPIKey("app "+name, app.APIKey, app.APIKeyHash); err != nil {
+		return err
+	}
+
+	labels := make(map[string]bool)
+	for _, key := range app.Keys() {
+		// the label names the key in the admin api, so it must not change
+		// when the keys are reordered
+		if key.Label == "" {
+			return fmt.Errorf("app %s has a key without label", name)
+		}
+		owner := fmt.Sprintf("app %s key %s", name, key.Label)
+		if labels[key.Label] {
+			return fmt.Errorf("app %s has more than one key labeled %s", name, key.Label)
+		}
+		labels[key.Label] = true
+
+		if key.Key == "" && key.KeyHash == "" {
+			return fmt.Errorf("%s has neither key nor key hash", owner)
+		}
+		if err := validateAPIKey(owner, key.Key, key.KeyHash); err != nil {
+			return err
+		}
+	}
+
+	return validatePolicy(app.Policy)
+}
+
+func validateAPIKey(owner, key, hash string) error {
+	if key != "" && hash != "" {
+		return fmt.Errorf("%s must not have both api key and api key hash", owner)
+	}
+
+	if hash != "" && !apikey.ValidHash(hash) {
+		return fmt.Errorf("%s has invalid api key hash, it must be a hex encoded sha-256", owner)
+	}
+
+	return nil
+}
+
+func lowerKeyHashes(app *AppConfig) {
+	if app == nil {
+		return
+	}
+
+	app.APIKeyHash = strings.ToLower(app.APIKeyHash)
+	for i := range app.APIKeys {
+		app.APIKeys[i].KeyHash = strings.ToLower(app.APIKeys[i].KeyHash)
+	}
+}
+
+func validatePolicy(policy *PolicyConfig) error {
+	if policy == nil {
+		return nil
+	}
+
+	for _, rules := range []DomainRules{policy.Allow, policy.Block} {
+		for _, pattern := range rules.Regex {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid policy regex %q: %w", pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func ensureNotNil(cfg *Config) {
 	if cfg.Log == nil {
 		cfg.Log = &LogConfig{}
@@ -50,9 +237,27 @@ func ensureNotNil(cfg *Config) {
 	if cfg.Valkey == nil {
 		cfg.Valkey = &Valkey{}
 	}
+	if cfg.Policy == nil {
+		cfg.Policy = &PolicyConfig{}
+	}
+	if cfg.Safety == nil {
+		cfg.Safety = &SafetyConfig{}
+	}
+	if cfg.Password == nil {
+		cfg.Password = &PasswordConfig{}
+	}
+	if cfg.Reports == nil {
+		cfg.Reports = &ReportsConfig{}
+	}
+	if cfg.Admin == nil {
+		cfg.Admin = &AdminConfig{}
+	}
 	if cfg.Public == nil {
 		cfg.Public = &AppConfig{}
 	}
+	if cfg.Domains == nil {
+		cfg.Domains = make(map[string]*DomainConfig)
+	}
 	if cfg.Apps == nil {
 		cfg.Apps = make(map[string]*AppConfig)
 	}
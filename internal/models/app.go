@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type App struct {
+	Name string `json:"name"`
+	// only returned when the app is created, unless it was created from a
+	// hash
+	APIKey              string   `json:"api_key,omitempty"`
+	Enabled             bool     `json:"enabled"`
+	FromConfig          bool     `json:"from_config"`
+	MinDurationSec      int      `json:"min_duration_sec"`
+	MaxDurationSec      int      `json:"max_duration_sec"`
+	RedirectTypes       []int    `json:"redirect_types"`
+	DefaultRedirectType int      `json:"default_redirect_type"`
+	AllowedSchemes      []string `json:"allowed_schemes"`
+	Domains             []string `json:"domains"`
+	Keys                []AppKey `json:"keys"`
+}
+
+// AppKey describes an API key of the app, never the key itself.
+type AppKey struct {
+	Label    string     `json:"label"`
+	NotAfter *time.Time `json:"not_after,omitempty"`
+	Expired  bool       `json:"expired"`
+}
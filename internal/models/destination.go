@@ -0,0 +1,8 @@
+package models
+
+// Destination is one of the URLs a link splits its traffic between, chosen
+// proportionally to its weight.
+type Destination struct {
+	URL    string `json:"url" validate:"required,link_url"`
+	Weight int    `json:"weight" validate:"omitempty,min=1,max=1000"`
+}
@@ -1,9 +1,30 @@
 package models
 
+import "time"
+
 type Link struct {
 	Slug        string `json:"slug"`
 	Domain      string `json:"domain"`
 	OriginalURL string `json:"original_url"`
 	URL         string `json:"url"`
 	TTL         int    `json:"ttl"`
+
+	Destinations []Destination `json:"destinations,omitempty"`
+	Sticky       string        `json:"sticky,omitempty"`
+	Rules        []Rule        `json:"rules,omitempty"`
+	ExpiresAt    *time.Time    `json:"expires_at,omitempty"`
+	NotBefore    *time.Time    `json:"not_before,omitempty"`
+
+	IdleTTL      int        `json:"idle_ttl,omitempty"`
+	MaxExpiresAt *time.Time `json:"max_expires_at,omitempty"`
+
+	FallbackURL string `json:"fallback_url,omitempty"`
+
+	RedirectType  int    `json:"redirect_type"`
+	ForwardQuery  bool   `json:"forward_query"`
+	QueryConflict string `json:"query_conflict,omitempty"`
+	ForwardPath   bool   `json:"forward_path"`
+	UTM           *UTM   `json:"utm,omitempty"`
+	Protected     bool   `json:"protected"`
+	MaxClicks     int    `json:"max_clicks,omitempty"`
 }
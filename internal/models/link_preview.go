@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type LinkPreview struct {
+	Slug              string     `json:"slug"`
+	Domain            string     `json:"domain"`
+	URL               string     `json:"url"`
+	Destination       string     `json:"destination"`
+	DestinationDomain string     `json:"destination_domain"`
+	CreatedAt         *time.Time `json:"created_at,omitempty"`
+	NotBefore         *time.Time `json:"not_before,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+type LinkStats struct {
+	Slug     string         `json:"slug"`
+	Domain   string         `json:"domain"`
+	Clicks   int64          `json:"clicks"`
+	Variants []VariantStats `json:"variants,omitempty"`
+}
+
+type VariantStats struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Clicks int64  `json:"clicks"`
+}
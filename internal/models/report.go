@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Report struct {
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details,omitempty"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+type QuarantinedLink struct {
+	Slug          string    `json:"slug"`
+	Domain        string    `json:"domain"`
+	OriginalURL   string    `json:"original_url"`
+	App           string    `json:"app,omitempty"`
+	APIKeyLabel   string    `json:"api_key_label,omitempty"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+	Reporters     int64     `json:"reporters"`
+	Reports       []Report  `json:"reports"`
+}
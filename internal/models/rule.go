@@ -0,0 +1,10 @@
+package models
+
+// Rule sends the requests matching all of its conditions to its URL, a
+// condition matches when any of its values does.
+type Rule struct {
+	URL       string   `json:"url" validate:"required,link_url"`
+	Platforms []string `json:"platforms,omitempty" validate:"required_without_all=Languages Referrers,omitempty,max=10,dive,platform"`
+	Languages []string `json:"languages,omitempty" validate:"omitempty,max=20,dive,language_tag"`
+	Referrers []string `json:"referrers,omitempty" validate:"omitempty,max=20,dive,hostname_rfc1123"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// StoredLink is the link data saved in valkey under "link:<domain>/<slug>",
+// it holds whatever is needed to redirect. Links created before it existed
+// are stored as the plain original URL.
+type StoredLink struct {
+	OriginalURL   string        `json:"original_url"`
+	Destinations  []Destination `json:"destinations,omitempty"`
+	Sticky        string        `json:"sticky,omitempty"`
+	Rules         []Rule        `json:"rules,omitempty"`
+	RedirectType  int           `json:"redirect_type,omitempty"`
+	ForwardQuery  bool          `json:"forward_query,omitempty"`
+	QueryConflict string        `json:"query_conflict,omitempty"`
+	ForwardPath   bool          `json:"forward_path,omitempty"`
+	UTM           *UTM          `json:"utm,omitempty"`
+	PasswordHash  string        `json:"password_hash,omitempty"`
+	MaxClicks     int           `json:"max_clicks,omitempty"`
+	NotBefore     *time.Time    `json:"not_before,omitempty"`
+	FallbackURL   string        `json:"fallback_url,omitempty"`
+	IdleTTL       int           `json:"idle_ttl,omitempty"`
+	MaxExpiresAt  *time.Time    `json:"max_expires_at,omitempty"`
+	QuarantinedAt *time.Time    `json:"quarantined_at,omitempty"`
+	App           string        `json:"app,omitempty"`
+	APIKeyLabel   string        `json:"api_key_label,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
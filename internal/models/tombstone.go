@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Tombstone is left in valkey under "tombstone:<domain>/<slug>" while a link
+// is alive and for a grace period after it expires, so the slug isn't reused.
+type Tombstone struct {
+	App         string    `json:"app,omitempty"`
+	FallbackURL string    `json:"fallback_url,omitempty"`
+	ExpiredAt   time.Time `json:"expired_at"`
+}
@@ -0,0 +1,9 @@
+package models
+
+type UTM struct {
+	Source   string `json:"source,omitempty" validate:"omitempty,max=100"`
+	Medium   string `json:"medium,omitempty" validate:"omitempty,max=100"`
+	Campaign string `json:"campaign,omitempty" validate:"omitempty,max=100"`
+	Term     string `json:"term,omitempty" validate:"omitempty,max=100"`
+	Content  string `json:"content,omitempty" validate:"omitempty,max=100"`
+}
@@ -2,10 +2,12 @@ package providers
 
 import (
 	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
 	"github.com/valkey-io/valkey-go"
 )
 
 type Providers struct {
 	Config *config.Config
 	Valkey valkey.Client
+	Apps   *apps.Registry
 }
@@ -0,0 +1,221 @@
+package app_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/api/app"
+	"github.com/pauloo27/shurl/internal/server/api/link"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/stretchr/testify/assert"
+	"github.com/valkey-io/valkey-go"
+)
+
+func mockValkey(t *testing.T) valkey.Client {
+	s := miniredis.RunT(t)
+
+	client, err := valkey.NewClient(valkey.ClientOption{
+		InitAddress:  []string{s.Addr()},
+		DisableCache: true,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	return client
+}
+
+func TestApps(t *testing.T) {
+	vkey := mockValkey(t)
+
+	static := &config.AppConfig{Name: "static", Enabled: true, APIKey: "static-key"}
+	cfg := &config.Config{
+		Public:      &config.AppConfig{},
+		Apps:        map[string]*config.AppConfig{"static": static},
+		AppByAPIKey: map[string]*config.AppConfig{apikey.Hash("static-key"): static},
+		Admin:       &config.AdminConfig{APIKey: "admin"},
+	}
+
+	registry := apps.NewRegistry(cfg, vkey)
+
+	e := echo.New()
+	app.NewAppController(cfg, registry).Route(e)
+	links, err := link.NewLinkController(cfg, vkey, registry)
+	assert.NoError(t, err)
+	links.Route(e)
+
+	serve := func(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Host = "localhost"
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	admin := map[string]string{"X-Admin-Key": "admin"}
+
+	createLink := func(apiKey string) int {
+		body := `{"original_url":"http://example.com","ttl":3600}`
+		return serve(http.MethodPost, "/api/v1/links", body, map[string]string{"X-API-Key": apiKey}).Code
+	}
+
+	t.Run("Admin key required", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/api/v1/admin/apps", "", nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"team"}`, nil).Code)
+	})
+
+	var apiKey string
+
+	t.Run("Create", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"team","max_duration_sec":7200}`, admin)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.App
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "team", created.Name)
+		assert.True(t, created.Enabled)
+		assert.False(t, created.FromConfig)
+		assert.Equal(t, 7200, created.MaxDurationSec)
+		assert.NotEmpty(t, created.APIKey)
+		apiKey = created.APIKey
+
+		assert.Equal(t, http.StatusCreated, createLink(apiKey))
+	})
+
+	t.Run("Create conflicts", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"team"}`, admin)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		rec = serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"other","api_key":"static-key-but-longer"}`, admin)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		rec = serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"another","api_key":"static-key-but-longer"}`, admin)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Create from hash", func(t *testing.T) {
+		body := `{"name":"hashed","api_key_hash":"` + apikey.Hash("hashed-key") + `"}`
+		rec := serve(http.MethodPost, "/api/v1/admin/apps", body, admin)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.App
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Empty(t, created.APIKey)
+
+		assert.Equal(t, http.StatusCreated, createLink("hashed-key"))
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/api/v1/admin/apps/hashed", "", admin).Code)
+
+		rec = serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"bad","api_key_hash":"nope"}`, admin)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"bad","redirect_types":[301],"default_redirect_type":302}`, admin)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var apiErr api.Error[map[string]string]
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &apiErr))
+		assert.Equal(t, api.ErrBadRequest.Name, apiErr.Error)
+		assert.Contains(t, apiErr.Detail["message"], "default redirect type")
+
+		rec = serve(http.MethodPost, "/api/v1/admin/apps", `{"name":"bad","redirect_types":[200]}`, admin)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/api/v1/admin/apps", "", admin)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), apiKey)
+
+		var listed []models.App
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+		assert.Len(t, listed, 3)
+		assert.Equal(t, "other", listed[0].Name)
+		assert.Equal(t, "static", listed[1].Name)
+		assert.True(t, listed[1].FromConfig)
+		assert.Equal(t, "team", listed[2].Name)
+	})
+
+	t.Run("Rotate keys", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/api/v1/admin/apps/team/keys", `{"label":"next"}`, admin)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var updated models.App
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+		assert.NotEmpty(t, updated.APIKey)
+		nextKey := updated.APIKey
+
+		assert.Len(t, updated.Keys, 2)
+		assert.Equal(t, config.DefaultAPIKeyLabel, updated.Keys[0].Label)
+		assert.Equal(t, "next", updated.Keys[1].Label)
+
+		// both keys work while the clients move to the new one
+		assert.Equal(t, http.StatusCreated, createLink(apiKey))
+		assert.Equal(t, http.StatusCreated, createLink(nextKey))
+
+		rec = serve(http.MethodPost, "/api/v1/admin/apps/team/keys", `{"label":"next"}`, admin)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		rec = serve(http.MethodPost, "/api/v1/admin/apps/team/keys", `{"label":"stolen","api_key":"static-key-but-longer"}`, admin)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		rec = serve(http.MethodPatch, "/api/v1/admin/apps/team/keys/default", `{"not_after":"2000-01-01T00:00:00Z"}`, admin)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+		assert.True(t, updated.Keys[0].Expired)
+		assert.False(t, updated.Keys[1].Expired)
+
+		assert.Equal(t, http.StatusUnauthorized, createLink(apiKey))
+		assert.Equal(t, http.StatusCreated, createLink(nextKey))
+
+		rec = serve(http.MethodDelete, "/api/v1/admin/apps/team/keys/default", "", admin)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+		assert.Len(t, updated.Keys, 1)
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/api/v1/admin/apps/team/keys/default", "", admin).Code)
+		assert.Equal(t, http.StatusConflict, serve(http.MethodDelete, "/api/v1/admin/apps/team/keys/next", "", admin).Code)
+		assert.Equal(t, http.StatusCreated, createLink(nextKey))
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "/api/v1/admin/apps/static/keys", `{"label":"next"}`, admin).Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/api/v1/admin/apps/nope/keys", `{"label":"next"}`, admin).Code)
+
+		apiKey = nextKey
+	})
+
+	t.Run("Disable", func(t *testing.T) {
+		rec := serve(http.MethodPatch, "/api/v1/admin/apps/team", `{"enabled":false}`, admin)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var updated models.App
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+		assert.False(t, updated.Enabled)
+		assert.Equal(t, 7200, updated.MaxDurationSec)
+
+		assert.Equal(t, http.StatusUnauthorized, createLink(apiKey))
+	})
+
+	t.Run("Config apps can't be changed", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodPatch, "/api/v1/admin/apps/static", `{"enabled":false}`, admin).Code)
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, "/api/v1/admin/apps/static", "", admin).Code)
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, "/api/v1/admin/apps/public", "", admin).Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/api/v1/admin/apps/team", "", admin).Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/api/v1/admin/apps/team", "", admin).Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodPatch, "/api/v1/admin/apps/team", `{}`, admin).Code)
+		assert.Equal(t, http.StatusUnauthorized, createLink(apiKey))
+	})
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+)
+
+type AppController struct {
+	cfg  *config.Config
+	apps *apps.Registry
+}
+
+func NewAppController(cfg *config.Config, registry *apps.Registry) *AppController {
+	return &AppController{
+		cfg:  cfg,
+		apps: registry,
+	}
+}
+
+func (c *AppController) Route(e *echo.Echo) {
+	e.GET("/api/v1/admin/apps", c.List)
+	e.POST("/api/v1/admin/apps", c.Create)
+	e.PATCH("/api/v1/admin/apps/:name", c.Update)
+	e.DELETE("/api/v1/admin/apps/:name", c.Delete)
+	e.POST("/api/v1/admin/apps/:name/keys", c.AddKey)
+	e.PATCH("/api/v1/admin/apps/:name/keys/:label", c.UpdateKey)
+	e.DELETE("/api/v1/admin/apps/:name/keys/:label", c.RemoveKey)
+}
+
+// AppSettingsBody has the app settings that can be changed at runtime, the
+// missing ones are left untouched.
+type AppSettingsBody struct {
+	Enabled             *bool    `json:"enabled"`
+	MinDurationSec      *int     `json:"min_duration_sec" validate:"omitempty,min=0,max=31536000"`
+	MaxDurationSec      *int     `json:"max_duration_sec" validate:"omitempty,min=0,max=31536000"`
+	RedirectTypes       []int    `json:"redirect_types" validate:"omitempty,dive,oneof=301 302 303 307 308"`
+	DefaultRedirectType *int     `json:"default_redirect_type" validate:"omitempty,oneof=0 301 302 303 307 308"`
+	AllowedSchemes      []string `json:"allowed_schemes" validate:"omitempty,dive,required,max=32"`
+	Domains             []string `json:"domains" validate:"omitempty,dive,required,max=253"`
+}
+
+func (body *AppSettingsBody) apply(app *config.AppConfig) {
+	if body.Enabled != nil {
+		app.Enabled = *body.Enabled
+	}
+	if body.MinDurationSec != nil {
+		app.MinDurationSec = *body.MinDurationSec
+	}
+	if body.MaxDurationSec != nil {
+		app.MaxDurationSec = *body.MaxDurationSec
+	}
+	if body.RedirectTypes != nil {
+		app.RedirectTypes = body.RedirectTypes
+	}
+	if body.DefaultRedirectType != nil {
+		app.DefaultRedirectType = *body.DefaultRedirectType
+	}
+	if body.AllowedSchemes != nil {
+		app.AllowedSchemes = body.AllowedSchemes
+	}
+	if body.Domains != nil {
+		app.Domains = body.Domains
+	}
+}
+
+func (c *AppController) toModel(app *config.AppConfig) models.App {
+	return models.App{
+		Name:                app.Name,
+		Enabled:             app.Enabled,
+		FromConfig:          c.apps.FromConfig(app.Name),
+		MinDurationSec:      app.MinDurationSec,
+		MaxDurationSec:      app.MaxDurationSec,
+		RedirectTypes:       app.RedirectTypes,
+		DefaultRedirectType: app.DefaultRedirectType,
+		AllowedSchemes:      app.AllowedSchemes,
+		Domains:             app.Domains,
+		Keys:                keysModel(app),
+	}
+}
+
+func keysModel(app *config.AppConfig) []models.AppKey {
+	now := time.Now()
+
+	keys := make([]models.AppKey, 0, len(app.APIKeys)+1)
+	for _, key := range app.Keys() {
+		keys = append(keys, models.AppKey{
+			Label:    key.Label,
+			NotAfter: key.NotAfter,
+			Expired:  !key.Active(now),
+		})
+	}
+	return keys
+}
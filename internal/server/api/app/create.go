@@ -0,0 +1,95 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/admin"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+)
+
+type CreateAppBody struct {
+	Name       string `json:"name" validate:"required,max=64,printascii,excludes=/"`
+	APIKey     string `json:"api_key" validate:"omitempty,min=16,max=128,excluded_with=APIKeyHash"`
+	APIKeyHash string `json:"api_key_hash" validate:"omitempty,len=64,hexadecimal"`
+	AppSettingsBody
+}
+
+// Create godoc
+//
+//	@Summary		Create an app
+//	@Description	Create an app at runtime, without restarting the server. It's available to every replica right away.
+//	@Description	When no API key (or API key hash) is sent, a random one is generated. The API key is only returned here,
+//	@Description	only its SHA-256 hash is stored.
+//	@Description	Apps are enabled by default.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Key	header		string			true	"Admin API Key"
+//	@Param			body		body		CreateAppBody	true	"Settings are optional"
+//	@Success		201			{object}	models.App
+//	@Failure		400			{object}	api.BadRequestError		"Bad request"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		409			{object}	api.ConflictError		"Name or API key already used"
+//	@Failure		422			{object}	api.ValidationError		"Validation error"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/apps [post]
+func (c *AppController) Create(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	body, validationErr := validator.MustBindAndValidate[CreateAppBody](ctx)
+	if validationErr != nil {
+		return ctx.JSON(api.DetailedError(validationErr.Error, validationErr.Details))
+	}
+
+	app := &config.AppConfig{
+		Name:       body.Name,
+		Enabled:    true,
+		APIKey:     body.APIKey,
+		APIKeyHash: strings.ToLower(body.APIKeyHash),
+	}
+	body.apply(app)
+
+	if app.APIKey == "" && app.APIKeyHash == "" {
+		apiKey, err := apikey.Generate()
+		if err != nil {
+			slog.Error("Failed to generate api key", "err", err)
+			return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+		}
+		app.APIKey = apiKey
+	}
+
+	// the registry only keeps the hash
+	plainKey := app.APIKey
+
+	if err := config.ValidateApp(app.Name, app); err != nil {
+		return ctx.JSON(api.Err(api.ErrBadRequest, err.Error()))
+	}
+
+	err := c.apps.Create(ctx.Request().Context(), app)
+	switch {
+	case errors.Is(err, apps.ErrExists):
+		return ctx.JSON(api.Err(api.ErrConflict, "App already exists"))
+	case errors.Is(err, apps.ErrAPIKeyUsed):
+		return ctx.JSON(api.Err(api.ErrConflict, "API key already used"))
+	case err != nil:
+		slog.Error("Failed to create app", "app", app.Name, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	slog.Info("App created", "app", app.Name, "ip", ctx.RealIP())
+
+	created := c.toModel(app)
+	created.APIKey = plainKey
+	return ctx.JSON(http.StatusCreated, created)
+}
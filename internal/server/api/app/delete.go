@@ -0,0 +1,50 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/admin"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+)
+
+// Delete godoc
+//
+//	@Summary		Delete an app
+//	@Description	Delete an app created at runtime, its API key stops working right away. Its links are kept.
+//	@Description	Apps defined in the config can't be deleted.
+//	@Tags			admin
+//	@Param			name		path	string	true	"Name of the app"
+//	@Param			X-Admin-Key	header	string	true	"Admin API Key"
+//	@Success		204			"Deleted"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		403			{object}	api.ForbiddenError		"App defined in the config"
+//	@Failure		404			{object}	api.NotFoundError		"App not found"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/apps/{name} [delete]
+func (c *AppController) Delete(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	name := ctx.Param("name")
+
+	err := c.apps.Delete(ctx.Request().Context(), name)
+	switch {
+	case errors.Is(err, apps.ErrFromConfig):
+		return ctx.JSON(api.Err(api.ErrForbidden, "App is defined in the config"))
+	case errors.Is(err, apps.ErrNotFound):
+		return ctx.JSON(api.Err(api.ErrNotFound, "App not found"))
+	case err != nil:
+		slog.Error("Failed to delete app", "app", name, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	slog.Info("App deleted", "app", name, "ip", ctx.RealIP())
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/admin"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+)
+
+type AddKeyBody struct {
+	Label      string     `json:"label" validate:"required,max=64,printascii,excludes=/"`
+	APIKey     string     `json:"api_key" validate:"omitempty,min=16,max=128,excluded_with=APIKeyHash"`
+	APIKeyHash string     `json:"api_key_hash" validate:"omitempty,len=64,hexadecimal"`
+	NotAfter   *time.Time `json:"not_after"`
+}
+
+type UpdateKeyBody struct {
+	NotAfter *time.Time `json:"not_after"`
+}
+
+// AddKey godoc
+//
+//	@Summary		Add an API key to an app
+//	@Description	Add another API key to an app created at runtime, the other keys keep working. That's how keys are rotated:
+//	@Description	add the new key, move the clients to it and then expire or remove the old one.
+//	@Description	When no API key (or API key hash) is sent, a random one is generated. The API key is only returned here.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			name		path		string		true	"Name of the app"
+//	@Param			X-Admin-Key	header		string		true	"Admin API Key"
+//	@Param			body		body		AddKeyBody	true	"The key never expires without not_after"
+//	@Success		201			{object}	models.App
+//	@Failure		400			{object}	api.BadRequestError		"Bad request"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		403			{object}	api.ForbiddenError		"App defined in the config"
+//	@Failure		404			{object}	api.NotFoundError		"App not found"
+//	@Failure		409			{object}	api.ConflictError		"Label or API key already used"
+//	@Failure		422			{object}	api.ValidationError		"Validation error"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/apps/{name}/keys [post]
+func (c *AppController) AddKey(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	app, ok, err := c.runtimeApp(ctx)
+	if !ok {
+		return err
+	}
+
+	body, validationErr := validator.MustBindAndValidate[AddKeyBody](ctx)
+	if validationErr != nil {
+		return ctx.JSON(api.DetailedError(validationErr.Error, validationErr.Details))
+	}
+
+	if keyIndex(app, body.Label) != -1 {
+		return ctx.JSON(api.Err(api.ErrConflict, "Key label already used"))
+	}
+
+	key := config.APIKeyConfig{
+		Label:    body.Label,
+		Key:      body.APIKey,
+		KeyHash:  strings.ToLower(body.APIKeyHash),
+		NotAfter: body.NotAfter,
+	}
+
+	if key.Key == "" && key.KeyHash == "" {
+		apiKey, err := apikey.Generate()
+		if err != nil {
+			slog.Error("Failed to generate api key", "err", err)
+			return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+		}
+		key.Key = apiKey
+	}
+
+	// the registry only keeps the hash
+	plainKey := key.Key
+	app.APIKeys = append(app.APIKeys, key)
+
+	if ok, err := c.updateKeys(ctx, app); !ok {
+		return err
+	}
+
+	slog.Info("App key added", "app", app.Name, "key", key.Label, "ip", ctx.RealIP())
+
+	updated := c.toModel(app)
+	updated.APIKey = plainKey
+	return ctx.JSON(http.StatusCreated, updated)
+}
+
+// UpdateKey godoc
+//
+//	@Summary		Change when an API key expires
+//	@Description	Set when an API key of an app created at runtime stops working, so the old key keeps working for a while after a
+//	@Description	rotation. Without not_after the key never expires.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			name		path		string			true	"Name of the app"
+//	@Param			label		path		string			true	"Label of the key"
+//	@Param			X-Admin-Key	header		string			true	"Admin API Key"
+//	@Param			body		body		UpdateKeyBody	true	"When the key expires"
+//	@Success		200			{object}	models.App
+//	@Failure		400			{object}	api.BadRequestError		"Bad request"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		403			{object}	api.ForbiddenError		"App defined in the config"
+//	@Failure		404			{object}	api.NotFoundError		"App or key not found"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/apps/{name}/keys/{label} [patch]
+func (c *AppController) UpdateKey(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	app, ok, err := c.runtimeApp(ctx)
+	if !ok {
+		return err
+	}
+
+	body, validationErr := validator.MustBindAndValidate[UpdateKeyBody](ctx)
+	if validationErr != nil {
+		return ctx.JSON(api.DetailedError(validationErr.Error, validationErr.Details))
+	}
+
+	label := ctx.Param("label")
+	i := keyIndex(app, label)
+	if i == -1 {
+		return ctx.JSON(api.Err(api.ErrNotFound, "Key not found"))
+	}
+	app.APIKeys[i].NotAfter = body.NotAfter
+
+	if ok, err := c.updateKeys(ctx, app); !ok {
+		return err
+	}
+
+	slog.Info("App key updated", "app", app.Name, "key", label, "not_after", body.NotAfter, "ip", ctx.RealIP())
+
+	return ctx.JSON(http.StatusOK, c.toModel(app))
+}
+
+// RemoveKey godoc
+//
+//	@Summary		Remove an API key from an app
+//	@Description	Remove an API key of an app created at runtime, it stops working right away. The last key of the app can't be
+//	@Description	removed, add the new one first.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			name		path		string	true	"Name of the app"
+//	@Param			label		path		string	true	"Label of the key"
+//	@Param			X-Admin-Key	header		string	true	"Admin API Key"
+//	@Success		200			{object}	models.App
+//	@Failure		400			{object}	api.BadRequestError		"Bad request"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		403			{object}	api.ForbiddenError		"App defined in the config"
+//	@Failure		404			{object}	api.NotFoundError		"App or key not found"
+//	@Failure		409			{object}	api.ConflictError		"Last key of the app"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/apps/{name}/keys/{label} [delete]
+func (c *AppController) RemoveKey(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	app, ok, err := c.runtimeApp(ctx)
+	if !ok {
+		return err
+	}
+
+	label := ctx.Param("label")
+	i := keyIndex(app, label)
+	if i == -1 {
+		return ctx.JSON(api.Err(api.ErrNotFound, "Key not found"))
+	}
+
+	if len(app.APIKeys) == 1 {
+		return ctx.JSON(api.Err(api.ErrConflict, "The last key of the app can't be removed"))
+	}
+	app.APIKeys = slices.Delete(app.APIKeys, i, i+1)
+
+	if ok, err := c.updateKeys(ctx, app); !ok {
+		return err
+	}
+
+	slog.Info("App key removed", "app", app.Name, "key", label, "ip", ctx.RealIP())
+
+	return ctx.JSON(http.StatusOK, c.toModel(app))
+}
+
+// runtimeApp finds the app of the request, writing the error response when
+// it's missing or defined in the config. The returned app is a copy with all
+// its keys in APIKeys, so they can be changed without touching the cached
+// one.
+func (c *AppController) runtimeApp(ctx echo.Context) (*config.AppConfig, bool, error) {
+	name := ctx.Param("name")
+
+	if c.apps.FromConfig(name) {
+		return nil, false, ctx.JSON(api.Err(api.ErrForbidden, "App is defined in the config"))
+	}
+
+	current := c.apps.ByName(name)
+	if current == nil {
+		return nil, false, ctx.JSON(api.Err(api.ErrNotFound, "App not found"))
+	}
+
+	app := *current
+	app.APIKeys = app.Keys()
+	app.APIKey, app.APIKeyHash = "", ""
+	return &app, true, nil
+}
+
+// updateKeys stores the changed keys of the app, writing the error response
+// when it fails.
+func (c *AppController) updateKeys(ctx echo.Context, app *config.AppConfig) (bool, error) {
+	if err := config.ValidateApp(app.Name, app); err != nil {
+		return false, ctx.JSON(api.Err(api.ErrBadRequest, err.Error()))
+	}
+
+	err := c.apps.Update(ctx.Request().Context(), app)
+	switch {
+	case errors.Is(err, apps.ErrNotFound):
+		return false, ctx.JSON(api.Err(api.ErrNotFound, "App not found"))
+	case errors.Is(err, apps.ErrAPIKeyUsed):
+		return false, ctx.JSON(api.Err(api.ErrConflict, "API key already used"))
+	case err != nil:
+		slog.Error("Failed to update app keys", "app", app.Name, "err", err)
+		return false, ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	return true, nil
+}
+
+func keyIndex(app *config.AppConfig, label string) int {
+	return slices.IndexFunc(app.APIKeys, func(key config.APIKeyConfig) bool {
+		return key.Label == label
+	})
+}
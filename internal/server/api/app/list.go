@@ -0,0 +1,34 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/admin"
+)
+
+// List godoc
+//
+//	@Summary		List apps
+//	@Description	List the apps, both the ones in the config and the ones created at runtime. API keys are not returned.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			X-Admin-Key	header		string	true	"Admin API Key"
+//	@Success		200			{array}		models.App
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/apps [get]
+func (c *AppController) List(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	apps := make([]models.App, 0)
+	for _, app := range c.apps.List() {
+		apps = append(apps, c.toModel(app))
+	}
+
+	return ctx.JSON(http.StatusOK, apps)
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/admin"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+)
+
+// Update godoc
+//
+//	@Summary		Update an app
+//	@Description	Enable, disable or change the limits of an app created at runtime, the missing settings are left untouched.
+//	@Description	Apps defined in the config can't be changed.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			name		path		string			true	"Name of the app"
+//	@Param			X-Admin-Key	header		string			true	"Admin API Key"
+//	@Param			body		body		AppSettingsBody	true	"Settings to change"
+//	@Success		200			{object}	models.App
+//	@Failure		400			{object}	api.BadRequestError		"Bad request"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		403			{object}	api.ForbiddenError		"App defined in the config"
+//	@Failure		404			{object}	api.NotFoundError		"App not found"
+//	@Failure		422			{object}	api.ValidationError		"Validation error"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/apps/{name} [patch]
+func (c *AppController) Update(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	name := ctx.Param("name")
+
+	if c.apps.FromConfig(name) {
+		return ctx.JSON(api.Err(api.ErrForbidden, "App is defined in the config"))
+	}
+
+	current := c.apps.ByName(name)
+	if current == nil {
+		return ctx.JSON(api.Err(api.ErrNotFound, "App not found"))
+	}
+
+	body, validationErr := validator.MustBindAndValidate[AppSettingsBody](ctx)
+	if validationErr != nil {
+		return ctx.JSON(api.DetailedError(validationErr.Error, validationErr.Details))
+	}
+
+	// the cached app is shared with in flight requests
+	app := *current
+	body.apply(&app)
+
+	if err := config.ValidateApp(app.Name, &app); err != nil {
+		return ctx.JSON(api.Err(api.ErrBadRequest, err.Error()))
+	}
+
+	err := c.apps.Update(ctx.Request().Context(), &app)
+	switch {
+	case errors.Is(err, apps.ErrNotFound):
+		return ctx.JSON(api.Err(api.ErrNotFound, "App not found"))
+	case err != nil:
+		slog.Error("Failed to update app", "app", name, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	slog.Info("App updated", "app", name, "enabled", app.Enabled, "ip", ctx.RealIP())
+
+	return ctx.JSON(http.StatusOK, c.toModel(&app))
+}
@@ -16,6 +16,13 @@ var (
 	ErrValidation     = ErrorType{"VALIDATION_ERROR", http.StatusUnprocessableEntity}
 	ErrUnauthorized   = ErrorType{"UNAUTHORIZED", http.StatusUnauthorized}
 	ErrNotImplemented = ErrorType{"NOT_IMPLEMENTED", http.StatusNotImplemented}
+
+	ErrDestinationBlocked = ErrorType{"DESTINATION_BLOCKED", http.StatusForbidden}
+	ErrUnknownDomain      = ErrorType{"UNKNOWN_DOMAIN", http.StatusMisdirectedRequest}
+	ErrTooManyRequests    = ErrorType{"TOO_MANY_REQUESTS", http.StatusTooManyRequests}
+	ErrGone               = ErrorType{"GONE", http.StatusGone}
+	ErrNotActive          = ErrorType{"NOT_ACTIVE", http.StatusForbidden}
+	ErrQuarantined        = ErrorType{"QUARANTINED", http.StatusForbidden}
 )
 
 type Error[T any] struct {
@@ -90,3 +97,28 @@ type NotFoundError struct {
 	Error  string            `json:"error" example:"NOT_FOUND"`
 	Detail map[string]string `json:"detail" example:"message:Error message"`
 }
+
+type UnknownDomainError struct {
+	Error  string            `json:"error" example:"UNKNOWN_DOMAIN"`
+	Detail map[string]string `json:"detail" example:"message:Error message"`
+}
+
+type TooManyRequestsError struct {
+	Error  string            `json:"error" example:"TOO_MANY_REQUESTS"`
+	Detail map[string]string `json:"detail" example:"message:Error message"`
+}
+
+type GoneError struct {
+	Error  string            `json:"error" example:"GONE"`
+	Detail map[string]string `json:"detail" example:"message:Error message"`
+}
+
+type QuarantinedError struct {
+	Error  string            `json:"error" example:"QUARANTINED"`
+	Detail map[string]string `json:"detail" example:"message:Error message"`
+}
+
+type NotActiveError struct {
+	Error  string            `json:"error" example:"NOT_ACTIVE"`
+	Detail map[string]string `json:"detail" example:"message:Error message,not_before:2025-01-01T00:00:00Z"`
+}
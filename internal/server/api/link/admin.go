@@ -0,0 +1,231 @@
+package link
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/admin"
+)
+
+// ListQuarantined godoc
+//
+//	@Summary		List quarantined links
+//	@Description	List the links quarantined by abuse reports, oldest first, with their latest reports.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			X-Admin-Key	header		string	true	"Admin API Key"
+//	@Success		200			{array}		models.QuarantinedLink
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/quarantine [get]
+func (c *LinkController) ListQuarantined(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	members, err := c.vkey.Do(ctx.Request().Context(), c.vkey.B().Zrange().Key(quarantineKey).Min("0").Max("-1").Build()).AsStrSlice()
+	if err != nil {
+		slog.Error("Failed to list quarantined links", "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	links := make([]models.QuarantinedLink, 0, len(members))
+	for _, member := range members {
+		domain, slug, _ := strings.Cut(member, "/")
+
+		link, found, err := c.getLink(ctx.Request().Context(), domain, slug)
+		if err != nil {
+			slog.Error("Failed to get link", "slug", slug, "err", err)
+			return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+		}
+
+		// expired while in quarantine
+		if !found || link.QuarantinedAt == nil {
+			cmd := c.vkey.B().Zrem().Key(quarantineKey).Member(member).Build()
+			if err := c.vkey.Do(ctx.Request().Context(), cmd).Error(); err != nil {
+				slog.Error("Failed to remove quarantined link", "slug", slug, "err", err)
+			}
+			continue
+		}
+
+		quarantined, err := c.quarantinedLink(ctx, domain, slug, link)
+		if err != nil {
+			slog.Error("Failed to get link reports", "slug", slug, "err", err)
+			return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+		}
+		links = append(links, quarantined)
+	}
+
+	return ctx.JSON(http.StatusOK, links)
+}
+
+func (c *LinkController) quarantinedLink(
+	ctx echo.Context, domain, slug string, link *models.StoredLink,
+) (models.QuarantinedLink, error) {
+	quarantined := models.QuarantinedLink{
+		Slug:          slug,
+		Domain:        domain,
+		OriginalURL:   link.OriginalURL,
+		App:           link.App,
+		APIKeyLabel:   link.APIKeyLabel,
+		QuarantinedAt: *link.QuarantinedAt,
+		Reports:       []models.Report{},
+	}
+
+	results := c.vkey.DoMulti(
+		ctx.Request().Context(),
+		c.vkey.B().Lrange().Key(reportsKey(domain, slug)).Start(0).Stop(-1).Build(),
+		c.vkey.B().Scard().Key(reportersKey(domain, slug)).Build(),
+	)
+
+	reports, err := results[0].AsStrSlice()
+	if err != nil {
+		return quarantined, err
+	}
+
+	for _, value := range reports {
+		var report models.Report
+		if err := json.Unmarshal([]byte(value), &report); err != nil {
+			return quarantined, err
+		}
+		quarantined.Reports = append(quarantined.Reports, report)
+	}
+
+	quarantined.Reporters, err = results[1].AsInt64()
+	return quarantined, err
+}
+
+// RestoreQuarantined godoc
+//
+//	@Summary		Restore a quarantined link
+//	@Description	Take a link out of quarantine, so it redirects again. Its reports are cleared.
+//	@Tags			admin
+//	@Param			domain		path	string	true	"Domain of the link"
+//	@Param			slug		path	string	true	"Slug of the link"
+//	@Param			X-Admin-Key	header	string	true	"Admin API Key"
+//	@Success		204			"Restored"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		404			{object}	api.NotFoundError		"Link not found or not quarantined"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/quarantine/{domain}/{slug}/restore [post]
+func (c *LinkController) RestoreQuarantined(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	slug := ctx.Param("slug")
+	domain, link, ok, err := c.findQuarantined(ctx, slug)
+	if !ok {
+		return err
+	}
+
+	if err := c.setQuarantined(ctx.Request().Context(), domain, slug, link, false); err != nil {
+		slog.Error("Failed to restore link", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	results := c.vkey.DoMulti(
+		ctx.Request().Context(),
+		c.vkey.B().Del().Key(reportsKey(domain, slug)).Build(),
+		c.vkey.B().Del().Key(reportersKey(domain, slug)).Build(),
+	)
+	for _, res := range results {
+		if err := res.Error(); err != nil {
+			slog.Error("Failed to clear link reports", "slug", slug, "err", err)
+		}
+	}
+
+	slog.Info("Quarantined link restored", "domain", domain, "slug", slug)
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// DeleteQuarantined godoc
+//
+//	@Summary		Delete a quarantined link
+//	@Description	Delete a quarantined link along with its stats and reports, it answers 410 Gone from now on. The slug can't be
+//	@Description	used again for a while, so it isn't taken over by the same abuse.
+//	@Tags			admin
+//	@Param			domain		path	string	true	"Domain of the link"
+//	@Param			slug		path	string	true	"Slug of the link"
+//	@Param			X-Admin-Key	header	string	true	"Admin API Key"
+//	@Success		204			"Deleted"
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid admin API Key"
+//	@Failure		404			{object}	api.NotFoundError		"Link not found or not quarantined"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		AdminKeyAuth
+//	@Router			/admin/quarantine/{domain}/{slug} [delete]
+func (c *LinkController) DeleteQuarantined(ctx echo.Context) error {
+	if !admin.IsAdmin(ctx, c.cfg.Admin) {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid admin key"))
+	}
+
+	slug := ctx.Param("slug")
+	domain, link, ok, err := c.findQuarantined(ctx, slug)
+	if !ok {
+		return err
+	}
+
+	// the tombstone replaces the one of the link, if any, so its fallback URL
+	// is gone too
+	err = c.setTombstone(ctx.Request().Context(), domain, slug, &models.Tombstone{
+		App:       link.App,
+		ExpiredAt: time.Now(),
+	}, deletedSlugGrace)
+	if err != nil {
+		slog.Error("Failed to set tombstone", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	// keys are deleted one by one, as they may live in different slots
+	results := c.vkey.DoMulti(
+		ctx.Request().Context(),
+		c.vkey.B().Del().Key(linkKey(domain, slug)).Build(),
+		c.vkey.B().Del().Key(clicksKey(domain, slug)).Build(),
+		c.vkey.B().Del().Key(statsKey(domain, slug)).Build(),
+		c.vkey.B().Del().Key(reportsKey(domain, slug)).Build(),
+		c.vkey.B().Del().Key(reportersKey(domain, slug)).Build(),
+		c.vkey.B().Zrem().Key(quarantineKey).Member(quarantineMember(domain, slug)).Build(),
+	)
+
+	for _, res := range results {
+		if err := res.Error(); err != nil {
+			slog.Error("Failed to delete link", "slug", slug, "err", err)
+			return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+		}
+	}
+
+	slog.Info("Quarantined link deleted", "domain", domain, "slug", slug, "url", link.OriginalURL)
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// findQuarantined finds the quarantined link of the request, writing the
+// error response when it's missing. The domain may be an alias, the canonical
+// one is returned.
+func (c *LinkController) findQuarantined(ctx echo.Context, slug string) (string, *models.StoredLink, bool, error) {
+	domain, _, found := c.resolveDomain(ctx.Param("domain"))
+	if !found {
+		return "", nil, false, ctx.JSON(api.Err(api.ErrNotFound, "Quarantined link not found"))
+	}
+
+	link, found, err := c.getLink(ctx.Request().Context(), domain, slug)
+	if err != nil {
+		slog.Error("Failed to get link", "slug", slug, "err", err)
+		return "", nil, false, ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !found || link.QuarantinedAt == nil {
+		return "", nil, false, ctx.JSON(api.Err(api.ErrNotFound, "Quarantined link not found"))
+	}
+
+	return domain, link, true, nil
+}
@@ -0,0 +1,35 @@
+package link
+
+import (
+	"net/url"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+)
+
+// appByName finds the app that created a link, nil when it's gone or the
+// link was created before apps were stored with it.
+func (c *LinkController) appByName(name string) *config.AppConfig {
+	if name == "" {
+		return nil
+	}
+
+	return c.apps.ByName(name)
+}
+
+// keyLabel names the API key used in the logs, empty when the request had
+// none.
+func keyLabel(key *config.APIKeyConfig) string {
+	if key == nil {
+		return ""
+	}
+	return key.Label
+}
+
+func (c *LinkController) linkUTMQuery(link *models.StoredLink) url.Values {
+	var appUTM *config.UTMConfig
+	if app := c.appByName(link.App); app != nil {
+		appUTM = app.UTM
+	}
+	return utmQuery(link.UTM, appUTM)
+}
@@ -0,0 +1,54 @@
+package link
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/valkey-io/valkey-go"
+)
+
+// useClickScript counts a click of a link limited to ARGV[1] clicks, returning
+// the remaining clicks or -1 when they are over. The counter expires with the
+// link (in ARGV[2] seconds), so it's not left behind.
+var useClickScript = valkey.NewLuaScript(`
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+if used >= tonumber(ARGV[1]) then
+	return -1
+end
+
+used = redis.call('INCR', KEYS[1])
+if used == 1 then
+	local ttl = tonumber(ARGV[2])
+	if ttl > 0 then
+		redis.call('EXPIRE', KEYS[1], ttl)
+	end
+end
+
+return tonumber(ARGV[1]) - used
+`)
+
+func clicksKey(domain, slug string) string {
+	return fmt.Sprintf("clicks:%s/%s", domain, slug)
+}
+
+// useClick counts a click of the link, telling if it can still be used.
+// Links without a clicks limit can always be used.
+func (c *LinkController) useClick(
+	ctx context.Context, domain, slug string, link *models.StoredLink, ttlSec int64,
+) (bool, error) {
+	if link.MaxClicks == 0 {
+		return true, nil
+	}
+
+	remaining, err := useClickScript.Exec(
+		ctx, c.vkey,
+		[]string{clicksKey(domain, slug)},
+		[]string{fmt.Sprint(link.MaxClicks), fmt.Sprint(ttlSec)},
+	).AsInt64()
+	if err != nil {
+		return false, err
+	}
+
+	return remaining >= 0, nil
+}
@@ -1,21 +1,69 @@
 package link
 
 import (
+	"html/template"
+
 	"github.com/labstack/echo/v4"
 	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/pauloo27/shurl/internal/server/core/policy"
+	"github.com/pauloo27/shurl/internal/server/core/safety"
 	"github.com/valkey-io/valkey-go"
 )
 
 type LinkController struct {
-	vkey valkey.Client
-	cfg  *config.Config
+	vkey   valkey.Client
+	cfg    *config.Config
+	apps   *apps.Registry
+	policy *policy.Engine
+	safety *safety.Checker
+
+	// destinations rejected by it are shown an interstitial
+	suspicious *policy.Policy
+
+	password config.PasswordConfig
+	reports  config.ReportsConfig
+
+	// custom error pages of the domains, by path
+	errorPages map[string]*template.Template
 }
 
-func NewLinkController(cfg *config.Config, vkey valkey.Client) *LinkController {
-	return &LinkController{vkey, cfg}
+func NewLinkController(cfg *config.Config, vkey valkey.Client, registry *apps.Registry) (*LinkController, error) {
+	errorPages, err := loadErrorPages(cfg.Domains)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &LinkController{
+		vkey:   vkey,
+		cfg:    cfg,
+		apps:   registry,
+		policy: policy.NewEngine(cfg.Policy),
+
+		password:   passwordSettings(cfg.Password),
+		reports:    reportsSettings(cfg.Reports),
+		errorPages: errorPages,
+	}
+
+	var suspicious *config.PolicyConfig
+	if cfg.Safety != nil {
+		suspicious = cfg.Safety.Suspicious
+	}
+	c.suspicious = policy.New(suspicious)
+
+	c.safety = safety.NewChecker(cfg.Safety, c.servedDomains(), c.lookupLink, c.namespace)
+	return c, nil
 }
 
 func (c *LinkController) Route(e *echo.Echo) {
 	e.POST("/api/v1/links", c.Create)
+	e.GET("/api/v1/links/:slug/stats", c.Stats)
+	e.POST("/api/v1/reports", c.Report)
+	e.GET("/api/v1/admin/quarantine", c.ListQuarantined)
+	e.POST("/api/v1/admin/quarantine/:domain/:slug/restore", c.RestoreQuarantined)
+	e.DELETE("/api/v1/admin/quarantine/:domain/:slug", c.DeleteQuarantined)
 	e.GET("/:slug", c.Redirect)
+	e.GET("/:slug/*", c.Redirect)
+	e.POST("/:slug", c.Unlock)
+	e.POST("/:slug/*", c.Unlock)
 }
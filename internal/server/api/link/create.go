@@ -2,9 +2,11 @@ package link
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -12,6 +14,9 @@ import (
 	"github.com/pauloo27/shurl/internal/config"
 	"github.com/pauloo27/shurl/internal/models"
 	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/password"
+	"github.com/pauloo27/shurl/internal/server/core/safety"
+	"github.com/pauloo27/shurl/internal/server/core/urlnorm"
 	"github.com/pauloo27/shurl/internal/server/core/validator"
 	"github.com/valkey-io/valkey-go"
 )
@@ -30,9 +35,57 @@ const (
 )
 
 type CreateLinkBody struct {
-	Slug        string `json:"slug" validate:"omitempty,min=3,max=20,excludes=/"`
-	OriginalURL string `json:"original_url" validate:"required,http_url"`
-	TTL         *int   `json:"ttl" validate:"min=0,max=31536000"`
+	Slug         string `json:"slug" validate:"omitempty,min=3,max=20,excludes=/,excludes=+"`
+	OriginalURL  string `json:"original_url" validate:"required_without=Destinations,excluded_with=Destinations,omitempty,link_url"`
+	TTL          *int   `json:"ttl" validate:"required_without_all=ExpiresAt IdleTTL,excluded_with=ExpiresAt,omitempty,min=0,max=31536000"`
+	Domain       string `json:"domain" validate:"omitempty,max=253"`
+	RedirectType int    `json:"redirect_type" validate:"omitempty,oneof=301 302 303 307 308"`
+
+	ExpiresAt   *time.Time `json:"expires_at"`
+	NotBefore   *time.Time `json:"not_before"`
+	FallbackURL string     `json:"fallback_url" validate:"omitempty,link_url"`
+	IdleTTL     int        `json:"idle_ttl" validate:"omitempty,min=1,max=31536000"`
+
+	ForwardQuery  bool   `json:"forward_query"`
+	QueryConflict string `json:"query_conflict" validate:"omitempty,oneof=keep override append"`
+	ForwardPath   bool   `json:"forward_path"`
+
+	Destinations []models.Destination `json:"destinations" validate:"omitempty,min=2,max=10,dive"`
+	Sticky       string               `json:"sticky" validate:"omitempty,oneof=cookie ip"`
+	Rules        []models.Rule        `json:"rules" validate:"omitempty,max=20,dive"`
+
+	UTM *models.UTM `json:"utm"`
+
+	Password  string `json:"password" validate:"omitempty,min=4,max=128"`
+	MaxClicks int    `json:"max_clicks" validate:"omitempty,min=1,max=1000000"`
+}
+
+func defaultRedirectType(app *config.AppConfig) int {
+	if app.DefaultRedirectType != 0 {
+		return app.DefaultRedirectType
+	}
+	return http.StatusTemporaryRedirect
+}
+
+func lowerAll(values []string) []string {
+	for i, value := range values {
+		values[i] = strings.ToLower(value)
+	}
+	return values
+}
+
+func appAllowsRedirectType(app *config.AppConfig, redirectType int) bool {
+	if len(app.RedirectTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range app.RedirectTypes {
+		if allowed == redirectType {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Create godoc
@@ -40,9 +93,32 @@ type CreateLinkBody struct {
 //	@Summary		Create a link
 //	@Description	Create a link from a slug to the original URL.
 //	@Description	If no slug is provided, a random one will be generated.
-//	@Description	The ttl is required. 0 means no expiration, otherwise it's the number of seconds until expiration.
-//	@Description	The ttl can't be greater than 1 year (31536000 seconds).
-//	@Description	The API Key may limit the ttl.
+//	@Description	Instead of the original URL, up to 10 destinations can be set to split the traffic between them, proportionally to their weights (1 by default).
+//	@Description	The sticky option sends the same visitor to the same destination, using a cookie or their IP.
+//	@Description	Rules send matching requests elsewhere, by platform (ios, android, windows, macos, linux, other, mobile or desktop),
+//	@Description	preferred language (pt matches pt-BR) and referrer domain (subdomains included). The first matching rule wins,
+//	@Description	the original URL (or destinations) is used when none match.
+//	@Description	The ttl is required, unless expires_at is set. 0 means no expiration, otherwise it's the number of seconds until expiration.
+//	@Description	The expires_at is the RFC 3339 time when the link expires, it can be used instead of the ttl.
+//	@Description	The not_before is the RFC 3339 time when the link starts redirecting, it may be up to 1 year from now.
+//	@Description	The link can't be active for more than 1 year (31536000 seconds).
+//	@Description	The idle_ttl makes the link expire that many seconds after it was last used instead, it can be combined with the ttl or expires_at to set an absolute expiration.
+//	@Description	Without an absolute expiration, idle links are still bounded by the API Key max duration.
+//	@Description	The API Key may limit for how long the link is active.
+//	@Description	Once expired, the link answers 410 Gone for a grace period set by the API Key, and the slug can't be used again in the meanwhile.
+//	@Description	The fallback_url is where the expired link redirects to in the grace period, instead of 410 Gone. The API Key may have a default one.
+//	@Description	The original URL scheme must be allowed by the API Key, http and https are allowed by default.
+//	@Description	The original URL may be normalized before being stored, depending on the API Key.
+//	@Description	The redirect type is optional, it's the status code used to redirect (301, 302, 303, 307 or 308).
+//	@Description	When missing, the API Key default is used (307 if not set). The API Key may limit the redirect types.
+//	@Description	When forward_query is set, the redirect request query is merged into the original URL query.
+//	@Description	The query_conflict chooses what happens to parameters in both: keep (default) the original ones, override them or append both.
+//	@Description	When forward_path is set, paths after the slug (/slug/extra/path) are appended to the original URL path.
+//	@Description	The utm parameters are added to the destination when redirecting, the API Key may have defaults for the missing ones.
+//	@Description	Parameters already in the destination are never overwritten.
+//	@Description	When a password is set, visitors must enter it before being redirected.
+//	@Description	When max_clicks is set, the link stops working (410 Gone) after being used that many times.
+//	@Description	The domain is optional, when provided it's used instead of the request host and must be allowed by the API Key.
 //	@Param			body	body	CreateLinkBody	true	"Slug is optional"
 //	@Tags			link
 //	@Produce		json
@@ -51,11 +127,12 @@ type CreateLinkBody struct {
 //	@Failure		400	{object}	api.BadRequestError		"Bad request"
 //	@Failure		500	{object}	api.InternalServerError	"Internal server error"
 //	@Failure		401	{object}	api.UnauthorizedError	"Missing API Key"
-//	@Failure		403	{object}	api.ForbiddenError		"Invalid API Key"
+//	@Failure		403	{object}	api.ForbiddenError		"Invalid API Key, blacklisted slug, domain not allowed or blocked destination"
 //	@Failure		409	{object}	api.ConflictError		"Duplicated link"
+//	@Failure		421	{object}	api.UnknownDomainError	"Unknown domain"
 //	@Failure		422	{object}	api.ValidationError		"Validation error"
 //	@Security		ApiKeyAuth
-//	@Param			X-API-Key	header	string	false	"API Key, leave empty for public access (if enabled in the server)"
+//	@Param			X-API-Key	header	string	false	"API Key, leave empty for public access (if enabled in the server or domain)"
 func (c *LinkController) Create(ctx echo.Context) error {
 	body, validationErr := validator.MustBindAndValidate[CreateLinkBody](ctx)
 	if validationErr != nil {
@@ -72,35 +149,106 @@ func (c *LinkController) Create(ctx echo.Context) error {
 		slug = randomSlug
 	}
 
-	if slugBlacklist[slug] {
-		return ctx.JSON(api.Err(api.ErrForbidden, "Slug is blacklisted"))
-	}
+	domain, domainCfg, found := c.resolveDomain(ctx.Request().Host)
 
-	var app *config.AppConfig
+	var (
+		app *config.AppConfig
+		key *config.APIKeyConfig
+	)
 
 	apiKey := ctx.Request().Header.Get("X-API-Key")
-	if apiKey == "" {
+	if apiKey == "" && found && domainCfg.DefaultApp != "" {
+		app = c.apps.ByName(domainCfg.DefaultApp)
+	} else if apiKey == "" {
 		app = c.cfg.Public
 	} else {
-		app = c.cfg.AppByAPIKey[apiKey]
+		app, key = c.apps.ByAPIKey(apiKey)
 	}
 
 	if app == nil || !app.Enabled {
 		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid API key"))
 	}
 
-	domain := ctx.Request().Host
+	// the request host is ignored when the domain is explicitly chosen
+	if body.Domain != "" {
+		domain, domainCfg, found = c.resolveDomain(body.Domain)
+		if !appAllowsDomain(app, body.Domain) && !appAllowsDomain(app, domain) {
+			return ctx.JSON(api.Err(api.ErrForbidden, "Domain not allowed for this API key"))
+		}
+	}
 
-	slog.Info("Creating link", "domain", domain, "slug", slug, "url", body.OriginalURL)
+	if !found {
+		return ctx.JSON(api.Err(api.ErrUnknownDomain, "Unknown domain"))
+	}
 
-	ttlInSecs := *body.TTL
-	var ttl time.Duration
+	if c.isSlugReserved(domainCfg, slug) {
+		return ctx.JSON(api.Err(api.ErrForbidden, "Slug is blacklisted"))
+	}
+
+	var destinations []models.Destination
+	for i, dest := range body.Destinations {
+		destURL, ok, err := c.checkDestination(ctx, app, domain, slug, fmt.Sprintf("destinations[%d].url", i), dest.URL)
+		if !ok {
+			return err
+		}
+
+		weight := dest.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		destinations = append(destinations, models.Destination{URL: destURL, Weight: weight})
+	}
+
+	var rules []models.Rule
+	for i, rule := range body.Rules {
+		ruleURL, ok, err := c.checkDestination(ctx, app, domain, slug, fmt.Sprintf("rules[%d].url", i), rule.URL)
+		if !ok {
+			return err
+		}
+
+		rule.URL = ruleURL
+		rule.Languages = lowerAll(rule.Languages)
+		rule.Referrers = lowerAll(rule.Referrers)
+		rules = append(rules, rule)
+	}
 
-	if ttlInSecs != 0 {
-		ttl = time.Duration(ttlInSecs) * time.Second
+	var originalURL string
+	if len(destinations) != 0 {
+		// the first destination is used where a single one is needed, such
+		// as previews
+		originalURL = destinations[0].URL
+	} else {
+		destURL, ok, err := c.checkDestination(ctx, app, domain, slug, "original_url", body.OriginalURL)
+		if !ok {
+			return err
+		}
+		originalURL = destURL
+	}
+
+	var fallbackURL string
+	if body.FallbackURL != "" {
+		destURL, ok, err := c.checkDestination(ctx, app, domain, slug, "fallback_url", body.FallbackURL)
+		if !ok {
+			return err
+		}
+		fallbackURL = destURL
 	}
 
-	if app.MaxDurationSec != 0 && ttlInSecs > app.MaxDurationSec {
+	slog.Info(
+		"Creating link",
+		"domain", domain, "slug", slug, "url", originalURL, "ip", ctx.RealIP(), "app", app.Name, "key", keyLabel(key),
+	)
+
+	now := time.Now()
+
+	schedule, scheduleErr := scheduleFromBody(now, &body, app)
+	if scheduleErr != nil {
+		return ctx.JSON(api.DetailedError(api.ErrValidation, []*validator.ValidationError{scheduleErr}))
+	}
+
+	ttlInSecs := int(schedule.activeDuration(now).Seconds())
+
+	if app.MaxDurationSec != 0 && int(schedule.maxActiveDuration(now).Seconds()) > app.MaxDurationSec {
 		return ctx.JSON(api.Err(api.ErrBadRequest, fmt.Sprintf("TTL too high, max is %d", app.MaxDurationSec)))
 	}
 
@@ -108,16 +256,108 @@ func (c *LinkController) Create(ctx echo.Context) error {
 		return ctx.JSON(api.Err(api.ErrBadRequest, fmt.Sprintf("TTL too low, min is %d", app.MinDurationSec)))
 	}
 
+	if fallbackURL != "" && app.ExpiredGraceSec == 0 {
+		return ctx.JSON(api.DetailedError(api.ErrValidation, []*validator.ValidationError{
+			{Field: "fallback_url", Error: "not_allowed"},
+		}))
+	}
+
+	redirectType := body.RedirectType
+	if redirectType == 0 {
+		redirectType = defaultRedirectType(app)
+	}
+
+	if !appAllowsRedirectType(app, redirectType) {
+		return ctx.JSON(api.DetailedError(api.ErrValidation, []*validator.ValidationError{
+			{Field: "redirect_type", Error: "not_allowed"},
+		}))
+	}
+
 	link := models.Link{
-		Slug:        slug,
-		Domain:      domain,
-		OriginalURL: body.OriginalURL,
-		TTL:         ttlInSecs,
-		URL:         fmt.Sprintf("https://%s/%s", domain, slug),
+		Slug:         slug,
+		Domain:       domain,
+		OriginalURL:  originalURL,
+		TTL:          ttlInSecs,
+		ExpiresAt:    schedule.ExpiresAt,
+		NotBefore:    schedule.NotBefore,
+		FallbackURL:  fallbackURL,
+		Destinations: destinations,
+		Rules:        rules,
+		IdleTTL:      body.IdleTTL,
+		MaxExpiresAt: schedule.MaxExpiresAt,
+		URL:          linkURL(ctx, domainCfg, domain, slug),
+		RedirectType: redirectType,
+	}
+
+	if len(destinations) != 0 {
+		link.Sticky = body.Sticky
+	}
+
+	if body.ForwardQuery {
+		link.ForwardQuery = true
+		link.QueryConflict = body.QueryConflict
+		if link.QueryConflict == "" {
+			link.QueryConflict = queryConflictKeep
+		}
+	}
+	link.ForwardPath = body.ForwardPath
+	link.UTM = body.UTM
+	link.MaxClicks = body.MaxClicks
+
+	var passwordHash string
+	if body.Password != "" {
+		var err error
+		passwordHash, err = password.Hash(body.Password)
+		if err != nil {
+			slog.Error("Failed to hash password", "err", err)
+			return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+		}
+		link.Protected = true
+	}
+
+	value, err := encodeLink(&models.StoredLink{
+		OriginalURL:   originalURL,
+		Destinations:  destinations,
+		Rules:         rules,
+		Sticky:        link.Sticky,
+		RedirectType:  redirectType,
+		ForwardQuery:  link.ForwardQuery,
+		QueryConflict: link.QueryConflict,
+		ForwardPath:   link.ForwardPath,
+		UTM:           link.UTM,
+		PasswordHash:  passwordHash,
+		MaxClicks:     link.MaxClicks,
+		NotBefore:     schedule.NotBefore,
+		FallbackURL:   fallbackURL,
+		IdleTTL:       body.IdleTTL,
+		MaxExpiresAt:  schedule.MaxExpiresAt,
+		App:           app.Name,
+		APIKeyLabel:   keyLabel(key),
+		CreatedAt:     now,
+	})
+	if err != nil {
+		slog.Error("Failed to encode link", "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	tombstoned, err := c.hasTombstone(ctx.Request().Context(), domain, slug)
+	if err != nil {
+		slog.Error("Failed to check tombstone", "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if tombstoned {
+		return ctx.JSON(api.Err(api.ErrConflict, "Link already exists or expired recently"))
 	}
 
-	key := fmt.Sprintf("link:%s/%s", domain, slug)
-	cmd := c.vkey.B().Set().Key(key).Value(body.OriginalURL).Nx().Ex(ttl).Build()
+	setCmd := c.vkey.B().Set().Key(linkKey(domain, slug)).Value(value).Nx()
+
+	var cmd valkey.Completed
+	if schedule.ExpiresAt != nil {
+		cmd = setCmd.Exat(*schedule.ExpiresAt).Build()
+	} else {
+		cmd = setCmd.Build()
+	}
 	res := c.vkey.Do(context.Background(), cmd)
 
 	if err := res.Error(); err != nil {
@@ -128,5 +368,57 @@ func (c *LinkController) Create(ctx echo.Context) error {
 		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
 	}
 
+	if schedule.ExpiresAt != nil && app.ExpiredGraceSec != 0 {
+		err := c.setTombstone(ctx.Request().Context(), domain, slug, &models.Tombstone{
+			App:         app.Name,
+			FallbackURL: fallbackURL,
+			ExpiredAt:   *schedule.ExpiresAt,
+		}, time.Duration(app.ExpiredGraceSec)*time.Second)
+		if err != nil {
+			slog.Error("Failed to set tombstone", "err", err)
+		}
+	}
+
+	// a counter left by an expired link with the same slug must not be reused
+	if link.MaxClicks != 0 {
+		if err := c.vkey.Do(context.Background(), c.vkey.B().Del().Key(clicksKey(domain, slug)).Build()).Error(); err != nil {
+			slog.Error("Failed to reset clicks", "err", err)
+		}
+	}
+
 	return ctx.JSON(http.StatusCreated, link)
 }
+
+// checkDestination normalizes a destination of the link and checks if it's
+// allowed, writing the error response when it's not.
+func (c *LinkController) checkDestination(
+	ctx echo.Context, app *config.AppConfig, domain, slug, field, rawURL string,
+) (string, bool, error) {
+	if !validator.SchemeAllowed(rawURL, app.AllowedSchemes) {
+		return "", false, ctx.JSON(api.DetailedError(api.ErrValidation, []*validator.ValidationError{
+			{Field: field, Error: "scheme_not_allowed"},
+		}))
+	}
+
+	destination, err := urlnorm.Normalize(rawURL, app.Normalize)
+	if err != nil {
+		return "", false, ctx.JSON(api.Err(api.ErrBadRequest, "Invalid "+strings.ReplaceAll(field, "_url", " URL")))
+	}
+
+	if err := c.policy.Check(app, destination); err != nil {
+		slog.Info("Destination rejected by policy", "url", destination, "err", err)
+		return "", false, ctx.JSON(api.Err(api.ErrDestinationBlocked, "Destination domain is not allowed"))
+	}
+
+	if err := c.safety.Check(ctx.Request().Context(), linkHost(ctx, domain), slug, destination); err != nil {
+		var unsafeErr *safety.UnsafeError
+		if errors.As(err, &unsafeErr) {
+			slog.Info("Unsafe destination rejected", "url", destination, "err", err)
+			return "", false, ctx.JSON(api.Err(api.ErrDestinationBlocked, unsafeErr.Reason))
+		}
+		slog.Error("Failed to check destination safety", "err", err)
+		return "", false, ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	return destination, true, nil
+}
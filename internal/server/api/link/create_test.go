@@ -1,14 +1,28 @@
 package link_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
 	"github.com/pauloo27/shurl/internal/server/api/link"
 	"github.com/pauloo27/shurl/internal/server/core/validator"
 	"github.com/stretchr/testify/assert"
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	maxDuration = 365 * 24 * time.Hour
 )
 
 func unmarshalAndValidate(raw string) (link.CreateLinkBody, bool) {
@@ -64,9 +78,490 @@ func TestValidateBody(t *testing.T) {
 		assert.False(t, ok)
 	})
 
+	t.Run("Original URL with custom scheme", func(t *testing.T) {
+		raw := `{"original_url":"mailto:someone@example.com","ttl":1}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.True(t, ok)
+	})
+
+	t.Run("Original URL with dangerous scheme", func(t *testing.T) {
+		raw := `{"original_url":"javascript:alert(1)","ttl":1}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.False(t, ok)
+	})
+
+	t.Run("Redirect type invalid", func(t *testing.T) {
+		raw := `{"original_url":"http://example.com","ttl":1,"redirect_type":200}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.False(t, ok)
+	})
+
 	t.Run("TTL not present", func(t *testing.T) {
 		raw := `{"original_url":"http://example.com"}`
 		_, ok := unmarshalAndValidate(raw)
 		assert.False(t, ok)
 	})
+
+	t.Run("Expires at instead of TTL", func(t *testing.T) {
+		raw := `{"original_url":"http://example.com","expires_at":"2030-01-01T00:00:00Z"}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.True(t, ok)
+	})
+
+	t.Run("Idle TTL instead of TTL", func(t *testing.T) {
+		raw := `{"original_url":"http://example.com","idle_ttl":60}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.True(t, ok)
+	})
+
+	t.Run("Destinations instead of original URL", func(t *testing.T) {
+		raw := `{"destinations":[{"url":"http://a.example.com","weight":3},{"url":"http://b.example.com"}],"ttl":1}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.True(t, ok)
+	})
+
+	t.Run("Both destinations and original URL", func(t *testing.T) {
+		raw := `{"original_url":"http://example.com","destinations":[{"url":"http://a.example.com"},{"url":"http://b.example.com"}],"ttl":1}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.False(t, ok)
+	})
+
+	t.Run("Single destination", func(t *testing.T) {
+		raw := `{"destinations":[{"url":"http://a.example.com"}],"ttl":1}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.False(t, ok)
+	})
+
+	t.Run("Invalid destination", func(t *testing.T) {
+		raw := `{"destinations":[{"url":"http://a.example.com"},{"url":"javascript:alert(1)"}],"ttl":1}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.False(t, ok)
+	})
+
+	t.Run("Both TTL and expires at", func(t *testing.T) {
+		raw := `{"original_url":"http://example.com","ttl":1,"expires_at":"2030-01-01T00:00:00Z"}`
+		_, ok := unmarshalAndValidate(raw)
+		assert.False(t, ok)
+	})
+}
+
+func TestCreate(t *testing.T) {
+	vkey := mockValkey()
+
+	app := &config.AppConfig{
+		Enabled: true,
+		APIKey:  "key",
+		Domains: []string{"sh.example.com"},
+	}
+
+	cfg := &config.Config{
+		Public: &config.AppConfig{Enabled: true},
+		Apps:   map[string]*config.AppConfig{"app": app},
+		AppByAPIKey: map[string]*config.AppConfig{
+			apikey.Hash("key"): app,
+		},
+		Domains: map[string]*config.DomainConfig{
+			"sh.example.com": {Scheme: "https", Aliases: []string{"s.example.com"}},
+			"localhost":      {},
+		},
+		DomainByAlias: map[string]string{"s.example.com": "sh.example.com"},
+	}
+
+	t.Run("Request host", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"slug":"host","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "localhost", created.Domain)
+		assert.Equal(t, "https://localhost/host", created.URL)
+	})
+
+	t.Run("Forwarded proto", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/links", strings.NewReader(`{"slug":"proto","original_url":"http://example.com","ttl":60}`))
+		req.Host = "localhost"
+		req.Header.Set("Content-Type", "application/json")
+		// only kept by the forwarded middleware when set by a trusted proxy
+		req.Header.Set(echo.HeaderXForwardedProto, "http")
+		rec := httptest.NewRecorder()
+		assert.NoError(t, newLinkController(cfg, vkey).Create(echo.New().NewContext(req, rec)))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "http://localhost/proto", created.URL)
+	})
+
+	t.Run("Unknown request host", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "shurl.internal.svc", "", `{"original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMisdirectedRequest, rec.Code)
+	})
+
+	t.Run("Chosen domain", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "shurl.internal.svc", "key", `{"slug":"chosen","domain":"sh.example.com","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "sh.example.com", created.Domain)
+		assert.Equal(t, "https://sh.example.com/chosen", created.URL)
+
+		res := vkey.Do(context.Background(), vkey.B().Exists().Key("link:sh.example.com/chosen").Build())
+		exists, _ := res.AsInt64()
+		assert.Equal(t, int64(1), exists)
+	})
+
+	t.Run("Alias request host", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "s.example.com", "", `{"slug":"alias","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "sh.example.com", created.Domain)
+		assert.Equal(t, "https://sh.example.com/alias", created.URL)
+	})
+
+	t.Run("Chosen alias domain", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "key", `{"slug":"chosen-alias","domain":"s.example.com","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "https://sh.example.com/chosen-alias", created.URL)
+	})
+
+	t.Run("Chain loop through alias", func(t *testing.T) {
+		chainCfg := *cfg
+		chainCfg.Safety = &config.SafetyConfig{AllowChaining: true}
+
+		body := `{"slug":"hop","domain":"sh.example.com","original_url":"https://s.example.com:443/chained","ttl":60}`
+		rec, err := callCreateHandler(&chainCfg, vkey, "localhost", "key", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		body = `{"slug":"chained","domain":"sh.example.com","original_url":"https://s.example.com/hop","ttl":60}`
+		rec, err = callCreateHandler(&chainCfg, vkey, "localhost", "key", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "loop")
+	})
+
+	t.Run("Redirect type", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"slug":"type","original_url":"http://example.com","ttl":60,"redirect_type":308}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, http.StatusPermanentRedirect, created.RedirectType)
+	})
+
+	t.Run("Redirect type not allowed for app", func(t *testing.T) {
+		cfg.Public.RedirectTypes = []int{http.StatusFound}
+		defer func() { cfg.Public.RedirectTypes = nil }()
+
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"original_url":"http://example.com","ttl":60,"redirect_type":301}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Chosen domain not allowed for app", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"domain":"sh.example.com","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Password", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"slug":"secret","original_url":"http://example.com","ttl":60,"password":"hunter2"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.True(t, created.Protected)
+
+		stored, err := vkey.Do(context.Background(), vkey.B().Get().Key("link:localhost/secret").Build()).ToString()
+		assert.NoError(t, err)
+		assert.NotContains(t, stored, "hunter2")
+		assert.Contains(t, stored, `"password_hash":"$argon2id$`)
+	})
+
+	t.Run("Max clicks resets old counter", func(t *testing.T) {
+		assert.NoError(t, vkey.Do(context.Background(), vkey.B().Set().Key("clicks:localhost/clicks").Value("3").Build()).Error())
+
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"slug":"clicks","original_url":"http://example.com","ttl":60,"max_clicks":3}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, 3, created.MaxClicks)
+
+		exists, err := vkey.Do(context.Background(), vkey.B().Exists().Key("clicks:localhost/clicks").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.Zero(t, exists)
+	})
+
+	t.Run("No expiration", func(t *testing.T) {
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"slug":"forever","original_url":"http://example.com","ttl":0}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key("link:localhost/forever").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-1), ttl)
+	})
+
+	t.Run("Scheduled", func(t *testing.T) {
+		notBefore := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+		expiresAt := notBefore.Add(2 * time.Hour)
+		body := fmt.Sprintf(
+			`{"slug":"event","original_url":"http://example.com","not_before":%q,"expires_at":%q}`,
+			notBefore.Format(time.RFC3339), expiresAt.Format(time.RFC3339),
+		)
+
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, 2*60*60, created.TTL)
+		assert.True(t, notBefore.Equal(*created.NotBefore))
+		assert.True(t, expiresAt.Equal(*created.ExpiresAt))
+
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key("link:localhost/event").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.InDelta(t, 26*60*60, ttl, 5)
+	})
+
+	t.Run("Invalid schedules", func(t *testing.T) {
+		now := time.Now().UTC()
+		format := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+		// field is empty for the errors with a message instead
+		cases := map[string]struct {
+			fields string
+			status int
+			field  string
+			error  string
+		}{
+			"expires in the past": {
+				fmt.Sprintf(`"expires_at":%q`, format(-time.Hour)),
+				http.StatusUnprocessableEntity, "expires_at", "in_past",
+			},
+			"starts after expiring": {
+				fmt.Sprintf(`"expires_at":%q,"not_before":%q`, format(time.Hour), format(2*time.Hour)),
+				http.StatusUnprocessableEntity, "not_before", "after_expires_at",
+			},
+			"starts too far": {
+				fmt.Sprintf(`"ttl":0,"not_before":%q`, format(2*maxDuration)),
+				http.StatusUnprocessableEntity, "not_before", "too_far",
+			},
+			"active for too long": {
+				fmt.Sprintf(`"expires_at":%q`, format(maxDuration+time.Hour)),
+				http.StatusUnprocessableEntity, "expires_at", "too_far",
+			},
+			"app duration too short": {
+				fmt.Sprintf(`"expires_at":%q,"not_before":%q`, format(2*time.Hour), format(2*time.Hour-time.Minute)),
+				http.StatusBadRequest, "", "TTL too low",
+			},
+		}
+
+		limited := *cfg.Public
+		limited.MinDurationSec = 60 * 60
+		limitedCfg := *cfg
+		limitedCfg.Public = &limited
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				rec, err := callCreateHandler(&limitedCfg, vkey, "localhost", "", `{"original_url":"http://example.com",`+c.fields+`}`)
+				assert.NoError(t, err)
+				assert.Equal(t, c.status, rec.Code)
+
+				if c.field == "" {
+					var res api.Error[map[string]string]
+					assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+					assert.Contains(t, res.Detail["message"], c.error)
+					return
+				}
+
+				var res api.Error[[]validator.ValidationError]
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				assert.Equal(t, []validator.ValidationError{{Field: c.field, Error: c.error}}, res.Detail)
+			})
+		}
+	})
+
+	t.Run("Expired slug can't be reused", func(t *testing.T) {
+		graceApp := &config.AppConfig{Name: "grace", Enabled: true, APIKey: "grace", ExpiredGraceSec: 60 * 60}
+		graceCfg := *cfg
+		graceCfg.AppByAPIKey = map[string]*config.AppConfig{apikey.Hash("grace"): graceApp}
+
+		body := `{"slug":"printed","original_url":"http://example.com","ttl":60,"fallback_url":"http://example.com/expired"}`
+		rec, err := callCreateHandler(&graceCfg, vkey, "localhost", "grace", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key("tombstone:localhost/printed").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.InDelta(t, 60*60+60, ttl, 5)
+
+		// simulate the link expiration
+		assert.NoError(t, vkey.Do(context.Background(), vkey.B().Del().Key("link:localhost/printed").Build()).Error())
+
+		rec, err = callCreateHandler(cfg, vkey, "localhost", "", `{"slug":"printed","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Rotated API keys", func(t *testing.T) {
+		expired := time.Now().Add(-time.Minute)
+		rotatedApp := &config.AppConfig{Name: "rotated", Enabled: true, APIKeys: []config.APIKeyConfig{
+			{Label: "old", Key: "old", NotAfter: &expired},
+			{Label: "new", Key: "new"},
+		}}
+		rotatedCfg := *cfg
+		rotatedCfg.AppByAPIKey = map[string]*config.AppConfig{
+			apikey.Hash("old"): rotatedApp,
+			apikey.Hash("new"): rotatedApp,
+		}
+
+		rec, err := callCreateHandler(&rotatedCfg, vkey, "localhost", "old", `{"slug":"rotated","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, err = callCreateHandler(&rotatedCfg, vkey, "localhost", "new", `{"slug":"rotated","original_url":"http://example.com","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		value, err := vkey.Do(context.Background(), vkey.B().Get().Key("link:localhost/rotated").Build()).ToString()
+		assert.NoError(t, err)
+
+		var stored models.StoredLink
+		assert.NoError(t, json.Unmarshal([]byte(value), &stored))
+		assert.Equal(t, "rotated", stored.App)
+		assert.Equal(t, "new", stored.APIKeyLabel)
+	})
+
+	t.Run("Fallback URL without grace period", func(t *testing.T) {
+		body := `{"slug":"fallback","original_url":"http://example.com","ttl":60,"fallback_url":"http://example.com/expired"}`
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Idle TTL", func(t *testing.T) {
+		idleApp := &config.AppConfig{Name: "idle", Enabled: true, APIKey: "idle", MaxDurationSec: 24 * 60 * 60}
+		idleCfg := *cfg
+		idleCfg.AppByAPIKey = map[string]*config.AppConfig{apikey.Hash("idle"): idleApp}
+
+		rec, err := callCreateHandler(&idleCfg, vkey, "localhost", "idle", `{"slug":"idle","original_url":"http://example.com","idle_ttl":3600}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, 3600, created.IdleTTL)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), *created.MaxExpiresAt, 5*time.Second)
+
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key("link:localhost/idle").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.InDelta(t, 3600, ttl, 5)
+
+		// bounded by the absolute expiration
+		rec, err = callCreateHandler(&idleCfg, vkey, "localhost", "idle", `{"slug":"idle-short","original_url":"http://example.com","idle_ttl":3600,"ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		ttl, err = vkey.Do(context.Background(), vkey.B().Ttl().Key("link:localhost/idle-short").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.InDelta(t, 60, ttl, 5)
+
+		// the absolute expiration can't go over the app max duration
+		rec, err = callCreateHandler(&idleCfg, vkey, "localhost", "idle", `{"slug":"idle-long","original_url":"http://example.com","idle_ttl":3600,"ttl":172800}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Destinations", func(t *testing.T) {
+		body := `{"slug":"split","destinations":[{"url":"http://A.example.com","weight":3},{"url":"http://b.example.com"}],"sticky":"ip","ttl":60}`
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "http://A.example.com", created.OriginalURL)
+		assert.Equal(t, []models.Destination{
+			{URL: "http://A.example.com", Weight: 3},
+			{URL: "http://b.example.com", Weight: 1},
+		}, created.Destinations)
+		assert.Equal(t, "ip", created.Sticky)
+	})
+
+	t.Run("Rules", func(t *testing.T) {
+		body := `{"slug":"rules","original_url":"http://example.com","ttl":60,"rules":[{"url":"http://example.com/pt","languages":["pt-BR"]}]}`
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var created models.Link
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, []models.Rule{{URL: "http://example.com/pt", Languages: []string{"pt-br"}}}, created.Rules)
+	})
+
+	t.Run("Rule destination not allowed", func(t *testing.T) {
+		body := `{"slug":"rules-mailto","original_url":"http://example.com","ttl":60,"rules":[{"url":"mailto:someone@example.com","platforms":["ios"]}]}`
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", body)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "rules[0].url")
+	})
+}
+
+func TestCreateDeepLinkWithSafety(t *testing.T) {
+	vkey := mockValkey()
+
+	cfg := &config.Config{
+		Public: &config.AppConfig{
+			Enabled:        true,
+			AllowedSchemes: []string{"http", "https", "mailto", "myapp"},
+		},
+		Policy: &config.PolicyConfig{Allow: config.DomainRules{Suffix: []string{"example.com"}}},
+		Safety: &config.SafetyConfig{BlockPrivateNetworks: true},
+	}
+
+	for _, destination := range []string{"myapp://open/item/1", "mailto:someone@example.org"} {
+		rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"original_url":"`+destination+`","ttl":60}`)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code, destination)
+	}
+
+	rec, err := callCreateHandler(cfg, vkey, "localhost", "", `{"original_url":"http://127.0.0.1/","ttl":60}`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func callCreateHandler(
+	cfg *config.Config, vkey valkey.Client,
+	domain, apiKey, body string,
+) (*httptest.ResponseRecorder, error) {
+	e := echo.New()
+	req := httptest.NewRequest("POST", "/api/v1/links", strings.NewReader(body))
+	req.Host = domain
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	c := newLinkController(cfg, vkey)
+	err := c.Create(ctx)
+	return rec, err
 }
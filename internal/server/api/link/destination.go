@@ -0,0 +1,132 @@
+package link
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+)
+
+const (
+	queryConflictKeep     = "keep"
+	queryConflictOverride = "override"
+	queryConflictAppend   = "append"
+)
+
+// buildDestination applies the link forwarding options to its original URL,
+// merging the request query and appending the path suffix after the slug.
+// The default query (such as UTM parameters) is added to http(s) URLs, without
+// overwriting the existing parameters.
+func buildDestination(
+	link *models.StoredLink, requestQuery url.Values, pathSuffix string,
+	defaultQuery url.Values,
+) (string, error) {
+	forwardQuery := link.ForwardQuery && len(requestQuery) > 0
+	forwardPath := link.ForwardPath && pathSuffix != ""
+
+	if !forwardQuery && !forwardPath && len(defaultQuery) == 0 {
+		return link.OriginalURL, nil
+	}
+
+	destination, err := url.Parse(link.OriginalURL)
+	if err != nil {
+		return "", err
+	}
+
+	if forwardPath && destination.Opaque == "" {
+		// cleaned first, so the suffix can't escape the original URL path
+		suffix := path.Clean("/" + pathSuffix)
+		if strings.HasSuffix(pathSuffix, "/") && suffix != "/" {
+			suffix += "/"
+		}
+		destination = destination.JoinPath(suffix)
+	}
+
+	if forwardQuery {
+		destination.RawQuery = appendQuery(destination.RawQuery, requestQuery, link.QueryConflict)
+	}
+
+	isHTTP := destination.Scheme == "http" || destination.Scheme == "https"
+	if isHTTP && len(defaultQuery) > 0 {
+		destination.RawQuery = appendQuery(destination.RawQuery, defaultQuery, queryConflictKeep)
+	}
+
+	return destination.String(), nil
+}
+
+// appendQuery adds the incoming parameters to rawQuery, leaving the existing
+// ones as they are (order and encoding included), unless overridden.
+func appendQuery(rawQuery string, incoming url.Values, conflict string) string {
+	var parts []string
+	existing := make(map[string]bool)
+
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+
+		rawKey, _, _ := strings.Cut(part, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+
+		if _, overridden := incoming[key]; overridden && conflict == queryConflictOverride {
+			continue
+		}
+
+		existing[key] = true
+		parts = append(parts, part)
+	}
+
+	keys := make([]string, 0, len(incoming))
+	for key := range incoming {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if existing[key] && conflict != queryConflictAppend {
+			continue
+		}
+
+		for _, value := range incoming[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// utmQuery builds the UTM parameters of a link, the app ones are used for
+// whatever the link doesn't set.
+func utmQuery(linkUTM *models.UTM, appUTM *config.UTMConfig) url.Values {
+	var fromLink, fromApp [5]string
+
+	if linkUTM != nil {
+		fromLink = [5]string{linkUTM.Source, linkUTM.Medium, linkUTM.Campaign, linkUTM.Term, linkUTM.Content}
+	}
+
+	if appUTM != nil {
+		fromApp = [5]string{appUTM.Source, appUTM.Medium, appUTM.Campaign, appUTM.Term, appUTM.Content}
+	}
+
+	keys := [5]string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content"}
+	query := make(url.Values)
+
+	for i, key := range keys {
+		value := fromLink[i]
+		if value == "" {
+			value = fromApp[i]
+		}
+
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	return query
+}
@@ -0,0 +1,119 @@
+package link
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+)
+
+const (
+	defaultScheme = "https"
+
+	// namespace of every link when no domains are configured, so the Host
+	// header sent by the client can't pick one
+	defaultNamespace = "default"
+)
+
+// resolveDomain finds the namespace (the canonical configured domain name)
+// and the settings for the request host, aliases included. When no domains
+// are configured, any host is accepted and the links share defaultNamespace.
+func (c *LinkController) resolveDomain(host string) (string, *config.DomainConfig, bool) {
+	if len(c.cfg.Domains) == 0 {
+		return defaultNamespace, &config.DomainConfig{}, true
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if name, domain, found := c.findDomain(host); found {
+		return name, domain, true
+	}
+
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return c.findDomain(hostname)
+	}
+
+	return "", nil, false
+}
+
+// namespace is the domain the links of host are stored under, the host itself
+// when it's not served.
+func (c *LinkController) namespace(host string) string {
+	if name, _, found := c.resolveDomain(host); found {
+		return name
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// findDomain looks up a domain by its name or by one of its aliases,
+// returning the canonical name.
+func (c *LinkController) findDomain(name string) (string, *config.DomainConfig, bool) {
+	if canonical, found := c.cfg.DomainByAlias[name]; found {
+		name = canonical
+	}
+
+	domain, found := c.cfg.Domains[name]
+	return name, domain, found
+}
+
+func (c *LinkController) servedDomains() []string {
+	domains := make([]string, 0, len(c.cfg.Domains)+len(c.cfg.DomainByAlias))
+	for name := range c.cfg.Domains {
+		domains = append(domains, name)
+	}
+	for alias := range c.cfg.DomainByAlias {
+		domains = append(domains, alias)
+	}
+	return domains
+}
+
+func (c *LinkController) isSlugReserved(domain *config.DomainConfig, slug string) bool {
+	if slugBlacklist[slug] {
+		return true
+	}
+
+	for _, reserved := range domain.ReservedSlugs {
+		if reserved == slug {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appAllowsDomain(app *config.AppConfig, domain string) bool {
+	for _, allowed := range app.Domains {
+		if strings.EqualFold(allowed, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkScheme is the scheme used in the short URLs. The forwarded proto only
+// reaches here when set by a trusted proxy, as the middleware drops it
+// otherwise. TLS is usually terminated upstream, so https is the default.
+func linkScheme(ctx echo.Context, domain *config.DomainConfig) string {
+	if domain.Scheme != "" {
+		return domain.Scheme
+	}
+	if proto := ctx.Request().Header.Get(echo.HeaderXForwardedProto); proto != "" {
+		return proto
+	}
+	return defaultScheme
+}
+
+func linkURL(ctx echo.Context, domainCfg *config.DomainConfig, domain, slug string) string {
+	return fmt.Sprintf("%s://%s/%s", linkScheme(ctx, domainCfg), linkHost(ctx, domain), slug)
+}
+
+// linkHost is the host of the short URLs of domain, the request one when no
+// domains are configured.
+func linkHost(ctx echo.Context, domain string) string {
+	if domain == defaultNamespace {
+		return ctx.Request().Host
+	}
+	return domain
+}
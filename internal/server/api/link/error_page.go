@@ -0,0 +1,76 @@
+package link
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/pages"
+)
+
+// loadErrorPages parses the custom error pages of the domains, keyed by path.
+func loadErrorPages(domains map[string]*config.DomainConfig) (map[string]*template.Template, error) {
+	errorPages := make(map[string]*template.Template)
+
+	for domain, domainCfg := range domains {
+		if domainCfg == nil {
+			continue
+		}
+		for _, path := range []string{domainCfg.NotFoundPage, domainCfg.GonePage} {
+			if path == "" {
+				continue
+			}
+			if _, loaded := errorPages[path]; loaded {
+				continue
+			}
+
+			page, err := pages.ParseCustom(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load error page %s of domain %s: %w", path, domain, err)
+			}
+			errorPages[path] = page
+		}
+	}
+
+	return errorPages, nil
+}
+
+// errorResponse shows browsers an HTML error page, the domain one when set,
+// while API clients get the JSON error.
+func (c *LinkController) errorResponse(
+	ctx echo.Context, domainCfg *config.DomainConfig, errType api.ErrorType, message string,
+) error {
+	if !pages.AcceptsHTML(ctx.Request()) {
+		return ctx.JSON(api.Err(errType, message))
+	}
+
+	data := pages.ErrorPage{
+		Status:  errType.StatusCode,
+		Title:   http.StatusText(errType.StatusCode),
+		Message: message,
+	}
+
+	if page, found := c.errorPages[customErrorPage(domainCfg, errType.StatusCode)]; found {
+		return pages.RenderCustom(ctx, errType.StatusCode, page, data)
+	}
+
+	return pages.Render(ctx, errType.StatusCode, "error", data)
+}
+
+func customErrorPage(domainCfg *config.DomainConfig, status int) string {
+	if domainCfg == nil {
+		return ""
+	}
+
+	switch status {
+	case http.StatusNotFound:
+		return domainCfg.NotFoundPage
+	case http.StatusGone:
+		return domainCfg.GonePage
+	default:
+		return ""
+	}
+}
@@ -1,7 +1,19 @@
 package link_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
 	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/api/link"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/stretchr/testify/assert"
 	"github.com/valkey-io/valkey-go"
 )
 
@@ -21,3 +33,69 @@ func mockValkey() valkey.Client {
 
 	return client
 }
+
+func newLinkController(cfg *config.Config, vkey valkey.Client) *link.LinkController {
+	c, err := link.NewLinkController(cfg, vkey, apps.NewRegistry(cfg, vkey))
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// acceptHTML are the headers of a browser request.
+var acceptHTML = map[string]string{"Accept": "text/html,application/xhtml+xml"}
+
+// setKey stores a value, expiring in ttl unless it's 0.
+func setKey(t *testing.T, vkey valkey.Client, key, value string, ttl time.Duration) {
+	cmd := vkey.B().Set().Key(key).Value(value)
+	if ttl != 0 {
+		assert.NoError(t, vkey.Do(context.Background(), cmd.Ex(ttl).Build()).Error())
+		return
+	}
+	assert.NoError(t, vkey.Do(context.Background(), cmd.Build()).Error())
+}
+
+// setLink stores a link of the default namespace (no domains configured),
+// expiring in ttl unless it's 0.
+func setLink(t *testing.T, vkey valkey.Client, slug, value string, ttl time.Duration) {
+	setKey(t, vkey, "link:default/"+slug, value, ttl)
+}
+
+// serve sends a request to localhost, unless a Host header is given. JSON
+// is the default content type of the body.
+func serve(
+	e *echo.Echo, method, target, body string, headers map[string]string, cookies ...*http.Cookie,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Host = "localhost"
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+
+	for key, value := range headers {
+		if key == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// serveRedirect sends a GET to a new router, as if it was to domain.
+func serveRedirect(
+	cfg *config.Config, vkey valkey.Client,
+	domain, target string,
+) *httptest.ResponseRecorder {
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+	return serve(e, http.MethodGet, target, "", map[string]string{"Host": domain})
+}
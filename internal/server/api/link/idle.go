@@ -0,0 +1,48 @@
+package link
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/valkey-io/valkey-go"
+)
+
+// touchLink slides the expiration of idle links on each use, along with the
+// keys that expire with them.
+func (c *LinkController) touchLink(ctx context.Context, domain, slug string, link *models.StoredLink) {
+	if link.IdleTTL == 0 {
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(link.IdleTTL) * time.Second)
+	if link.MaxExpiresAt != nil && link.MaxExpiresAt.Before(expiresAt) {
+		expiresAt = *link.MaxExpiresAt
+	}
+
+	cmds := valkey.Commands{
+		c.vkey.B().Expireat().Key(linkKey(domain, slug)).Timestamp(expiresAt.Unix()).Build(),
+	}
+
+	cmds = append(cmds,
+		c.vkey.B().Expireat().Key(statsKey(domain, slug)).Timestamp(expiresAt.Unix()).Build(),
+		c.vkey.B().Expireat().Key(reportsKey(domain, slug)).Timestamp(expiresAt.Unix()).Build(),
+		c.vkey.B().Expireat().Key(reportersKey(domain, slug)).Timestamp(expiresAt.Unix()).Build(),
+	)
+
+	if link.MaxClicks != 0 {
+		cmds = append(cmds, c.vkey.B().Expireat().Key(clicksKey(domain, slug)).Timestamp(expiresAt.Unix()).Build())
+	}
+
+	if app := c.appByName(link.App); app != nil && app.ExpiredGraceSec != 0 {
+		graceExpiresAt := expiresAt.Add(time.Duration(app.ExpiredGraceSec) * time.Second)
+		cmds = append(cmds, c.vkey.B().Expireat().Key(tombstoneKey(domain, slug)).Timestamp(graceExpiresAt.Unix()).Build())
+	}
+
+	for _, res := range c.vkey.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			slog.Error("Failed to refresh idle link", "slug", slug, "err", err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package link
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/pages"
+)
+
+type interstitialPage struct {
+	URL               string
+	Destination       string
+	DestinationDomain string
+	Suspicious        bool
+}
+
+// interstitial tells if the link must ask before redirecting, because its
+// app wants so or because the destination is suspicious.
+func (c *LinkController) interstitial(link *models.StoredLink, destination string) (show bool, suspicious bool) {
+	if u, err := url.Parse(destination); err == nil && u.Host != "" {
+		suspicious = c.suspicious.Check(u.Hostname()) != nil
+	}
+
+	app := c.appByName(link.App)
+	return suspicious || (app != nil && app.Interstitial), suspicious
+}
+
+// showInterstitial asks browsers to continue to the destination with a plain
+// link, so it works without JavaScript.
+func showInterstitial(
+	ctx echo.Context, domainCfg *config.DomainConfig, domain, slug, destination string, suspicious bool,
+) error {
+	page := interstitialPage{
+		URL:         linkURL(ctx, domainCfg, domain, slug),
+		Destination: destination,
+		Suspicious:  suspicious,
+	}
+
+	if u, err := url.Parse(destination); err == nil {
+		page.DestinationDomain = u.Hostname()
+	}
+
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return pages.Render(ctx, http.StatusOK, "interstitial", page)
+}
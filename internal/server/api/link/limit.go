@@ -0,0 +1,26 @@
+package link
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/valkey-io/valkey-go"
+)
+
+// countAttemptScript counts an attempt in a window of ARGV[1] seconds,
+// returning the attempts so far. The window starts on the first attempt, a
+// counter left without expiration is given one, so it can't lock forever.
+var countAttemptScript = valkey.NewLuaScript(`
+local attempts = redis.call('INCR', KEYS[1])
+if attempts == 1 or redis.call('TTL', KEYS[1]) == -1 then
+	redis.call('EXPIRE', KEYS[1], tonumber(ARGV[1]))
+end
+
+return attempts
+`)
+
+// countAttempt counts an attempt under key, returning the attempts in the
+// current window, this one included.
+func (c *LinkController) countAttempt(ctx context.Context, key string, windowSec int) (int64, error) {
+	return countAttemptScript.Exec(ctx, c.vkey, []string{key}, []string{fmt.Sprint(windowSec)}).AsInt64()
+}
@@ -0,0 +1,79 @@
+package link
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/pages"
+)
+
+func isPreview(ctx echo.Context, slug string) bool {
+	return strings.HasSuffix(slug, "+") || ctx.QueryParam("preview") == "1"
+}
+
+// preview shows where the link goes, as an HTML page for browsers and as JSON
+// for API clients.
+func (c *LinkController) preview(
+	ctx echo.Context, domain string, domainCfg *config.DomainConfig, slug string,
+) error {
+	link, ttl, found, err := c.getLinkWithTTL(ctx.Request().Context(), domain, slug)
+	if err != nil {
+		slog.Error("Failed to get link", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !found {
+		return c.linkNotFound(ctx, domain, domainCfg, slug)
+	}
+
+	if link.QuarantinedAt != nil {
+		return showQuarantine(ctx, domainCfg, domain, slug)
+	}
+
+	if !c.isUnlocked(ctx, domain, slug, link) {
+		return locked(ctx, api.ErrUnauthorized, domainCfg, domain, slug, "")
+	}
+
+	destination, err := buildDestination(link, nil, "", c.linkUTMQuery(link))
+	if err != nil {
+		slog.Error("Failed to build destination", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	preview := models.LinkPreview{
+		Slug:        slug,
+		Domain:      domain,
+		URL:         linkURL(ctx, domainCfg, domain, slug),
+		Destination: destination,
+	}
+
+	if u, err := url.Parse(destination); err == nil {
+		preview.DestinationDomain = u.Hostname()
+	}
+
+	preview.NotBefore = link.NotBefore
+
+	if !link.CreatedAt.IsZero() {
+		preview.CreatedAt = &link.CreatedAt
+	}
+
+	if ttl >= 0 {
+		expiresAt := time.Now().Add(time.Duration(ttl) * time.Second).Truncate(time.Second)
+		preview.ExpiresAt = &expiresAt
+	}
+
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	if pages.AcceptsHTML(ctx.Request()) {
+		return pages.Render(ctx, http.StatusOK, "preview", preview)
+	}
+
+	return ctx.JSON(http.StatusOK, preview)
+}
@@ -0,0 +1,74 @@
+package link
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/pages"
+)
+
+const (
+	// sorted set of the quarantined links ("<domain>/<slug>"), by the time
+	// they were quarantined
+	quarantineKey = "quarantine"
+
+	// how long the slug of a deleted quarantined link can't be used again
+	deletedSlugGrace = 30 * 24 * time.Hour
+)
+
+type quarantinePage struct {
+	URL string
+}
+
+func quarantineMember(domain, slug string) string {
+	return fmt.Sprintf("%s/%s", domain, slug)
+}
+
+// setQuarantined flags (or unflags) a link, without touching its expiration.
+func (c *LinkController) setQuarantined(
+	ctx context.Context, domain, slug string, link *models.StoredLink, quarantined bool,
+) error {
+	now := time.Now()
+
+	if quarantined {
+		link.QuarantinedAt = &now
+	} else {
+		link.QuarantinedAt = nil
+	}
+
+	value, err := encodeLink(link)
+	if err != nil {
+		return err
+	}
+
+	cmd := c.vkey.B().Set().Key(linkKey(domain, slug)).Value(value).Xx().Keepttl().Build()
+	if err := c.vkey.Do(ctx, cmd).Error(); err != nil {
+		return err
+	}
+
+	if quarantined {
+		cmd = c.vkey.B().Zadd().Key(quarantineKey).ScoreMember().ScoreMember(float64(now.Unix()), quarantineMember(domain, slug)).Build()
+	} else {
+		cmd = c.vkey.B().Zrem().Key(quarantineKey).Member(quarantineMember(domain, slug)).Build()
+	}
+	return c.vkey.Do(ctx, cmd).Error()
+}
+
+// showQuarantine warns browsers that the link was disabled, without telling
+// where it goes, API clients get an error instead.
+func showQuarantine(ctx echo.Context, domainCfg *config.DomainConfig, domain, slug string) error {
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	if pages.AcceptsHTML(ctx.Request()) {
+		return pages.Render(ctx, api.ErrQuarantined.StatusCode, "quarantine", quarantinePage{
+			URL: linkURL(ctx, domainCfg, domain, slug),
+		})
+	}
+
+	return ctx.JSON(api.Err(api.ErrQuarantined, "Link was reported as abusive and is under review"))
+}
@@ -1,49 +1,178 @@
 package link
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
 	"github.com/pauloo27/shurl/internal/server/api"
-	"github.com/valkey-io/valkey-go"
+	"github.com/pauloo27/shurl/internal/server/core/targeting"
+	"github.com/pauloo27/shurl/internal/server/pages"
+)
+
+const (
+	maxPermanentCacheAgeSec = 365 * 24 * 60 * 60
 )
 
 // Redirect godoc
 //
 //	@Summary		Redirect to the original URL
-//	@Description	Redirect from domain/slug to the original URL
+//	@Description	Redirect from domain/slug to the original URL, using the link redirect type.
+//	@Description	Temporary redirects are not cached, permanent ones are cached until the link expires.
+//	@Description	UTM parameters of the link (or the API Key defaults) are added to the destination, unless already present.
+//	@Description	Adding a + to the slug (/{slug}+) or the preview=1 query shows a preview of the link instead.
+//	@Description	Paths after the slug (/{slug}/extra/path) are only accepted by links that forward paths.
+//	@Description	Links with multiple destinations pick one per request, by weight or sticky to the visitor.
+//	@Description	Links with rules send the requests matching them (by platform, language and referrer) to the rule destination.
+//	@Description	Browsers are shown an interstitial page before leaving, for links of some API Keys or suspicious destinations.
+//	@Description	Each use of idle links pushes their expiration back.
+//	@Description	Recently expired links answer 410 Gone, or redirect to their fallback URL.
+//	@Description	Links with a not_before in the future are not active yet, the domain may redirect them somewhere else.
+//	@Description	Links limited by clicks answer 410 Gone once all the clicks are used, previews are not counted.
+//	@Description	Browsers get HTML error pages (the domain may customize them) instead of JSON errors.
+//	@Description	Links quarantined by abuse reports show a warning page (403) instead of redirecting.
+//	@Description	Password protected links ask for the password first (an HTML form for browsers), see the POST route.
 //	@Tags			link
 //	@Param			slug	path	string	true	"Slug to redirect from"
-//	@Success		307
-//	@Failure		404	{object}	api.NotFoundError		"Link not found"
-//	@Failure		500	{object}	api.InternalServerError	"Internal server error"
+//	@Success		301		"Moved permanently"
+//	@Success		302		"Found, also used for missing, expired or inactive links when the domain or API Key has a fallback"
+//	@Success		303		"See other"
+//	@Success		307		"Temporary redirect"
+//	@Success		308		"Permanent redirect"
+//	@Success		200		{object}	models.LinkPreview		"Link preview, HTML for browsers. Also the interstitial page"
+//	@Failure		401		{object}	api.UnauthorizedError	"Password protected link, HTML form for browsers"
+//	@Failure		403		{object}	api.NotActiveError		"Link not active yet, or quarantined (QUARANTINED error)"
+//	@Failure		404		{object}	api.NotFoundError		"Link not found"
+//	@Failure		410		{object}	api.GoneError			"Link has no clicks left or expired recently"
+//	@Failure		421		{object}	api.UnknownDomainError	"Unknown domain"
+//	@Failure		500		{object}	api.InternalServerError	"Internal server error"
 //	@Router			/{slug} [get]
 func (c *LinkController) Redirect(ctx echo.Context) error {
-	domain := ctx.Request().Host
 	slug := ctx.Param("slug")
 
+	domain, domainCfg, found := c.resolveDomain(ctx.Request().Host)
+	if !found {
+		return c.errorResponse(ctx, nil, api.ErrUnknownDomain, "Unknown domain")
+	}
+
 	slog.Info("h-hello?", "slug", slug, "domain", domain)
-	key := fmt.Sprintf("link:%s/%s", domain, slug)
 
-	cmd := c.vkey.B().Get().Key(key).Build()
-	res := c.vkey.Do(context.Background(), cmd)
+	if isPreview(ctx, slug) {
+		return c.preview(ctx, domain, domainCfg, strings.TrimSuffix(slug, "+"))
+	}
 
-	if err := res.Error(); err != nil {
-		if valkey.IsValkeyNil(err) {
-			return ctx.JSON(api.Err(api.ErrNotFound, "Link not found"))
-		}
+	link, ttl, found, err := c.getLinkWithTTL(ctx.Request().Context(), domain, slug)
+	if err != nil {
 		slog.Error("Failed to get link", "slug", slug, "err", err)
 		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
 	}
 
-	value, err := res.ToString()
+	if !found {
+		return c.linkNotFound(ctx, domain, domainCfg, slug)
+	}
+
+	if link.QuarantinedAt != nil {
+		return showQuarantine(ctx, domainCfg, domain, slug)
+	}
+
+	// checked first, so the schedule of protected links isn't leaked
+	if !c.isUnlocked(ctx, domain, slug, link) {
+		return locked(ctx, api.ErrUnauthorized, domainCfg, domain, slug, "")
+	}
+
+	if link.NotBefore != nil && time.Now().Before(*link.NotBefore) {
+		return c.notActive(ctx, domainCfg, *link.NotBefore)
+	}
+
+	pathSuffix := ctx.Param("*")
+	if pathSuffix != "" && !link.ForwardPath {
+		return c.notFound(ctx, domainCfg)
+	}
+
+	usable, err := c.useClick(ctx.Request().Context(), domain, slug, link, ttl)
+	if err != nil {
+		slog.Error("Failed to count click", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !usable {
+		return c.errorResponse(ctx, domainCfg, api.ErrGone, "Link is no longer available")
+	}
+
+	target := link
+	variant := -1
+	if rule, matched := targeting.Pick(link.Rules, ctx.Request()); matched {
+		target = withOriginalURL(link, link.Rules[rule].URL)
+	} else if variant = pickVariant(ctx, domainCfg, domain, slug, link); variant >= 0 {
+		target = withOriginalURL(link, link.Destinations[variant].URL)
+	}
+
+	destination, err := buildDestination(target, ctx.QueryParams(), pathSuffix, c.linkUTMQuery(link))
 	if err != nil {
-		slog.Error("Failed to parse string value", "slug", slug, "err", err)
+		slog.Error("Failed to build destination", "slug", slug, "err", err)
 		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
 	}
 
-	return ctx.Redirect(http.StatusTemporaryRedirect, value)
+	c.recordClick(ctx.Request().Context(), domain, slug, variant, ttl)
+
+	c.touchLink(ctx.Request().Context(), domain, slug, link)
+
+	if show, suspicious := c.interstitial(link, destination); show && pages.AcceptsHTML(ctx.Request()) {
+		return showInterstitial(ctx, domainCfg, domain, slug, destination, suspicious)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderCacheControl, cacheControl(link, ttl))
+	return ctx.Redirect(link.RedirectType, destination)
+}
+
+func (c *LinkController) notFound(ctx echo.Context, domainCfg *config.DomainConfig) error {
+	if domainCfg.NotFoundURL != "" {
+		return ctx.Redirect(http.StatusFound, domainCfg.NotFoundURL)
+	}
+	return c.errorResponse(ctx, domainCfg, api.ErrNotFound, "Link not found")
+}
+
+func (c *LinkController) notActive(ctx echo.Context, domainCfg *config.DomainConfig, notBefore time.Time) error {
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	ctx.Response().Header().Set(echo.HeaderRetryAfter, notBefore.UTC().Format(http.TimeFormat))
+
+	if domainCfg.NotActiveURL != "" {
+		return ctx.Redirect(http.StatusFound, domainCfg.NotActiveURL)
+	}
+
+	if pages.AcceptsHTML(ctx.Request()) {
+		return c.errorResponse(ctx, domainCfg, api.ErrNotActive, fmt.Sprintf(
+			"Link is not active until %s", notBefore.UTC().Format("2006-01-02 15:04 MST"),
+		))
+	}
+
+	return ctx.JSON(api.DetailedError(api.ErrNotActive, map[string]string{
+		"message":    "Link is not active yet",
+		"not_before": notBefore.UTC().Format(time.RFC3339),
+	}))
+}
+
+// cacheControl tells browsers and proxies to never cache temporary redirects,
+// links that must see every use (limited by clicks or idle), that choose the
+// destination per request or that are password protected (a shared cache would
+// skip the password), while permanent ones are cached until the link expires
+// (ttlSec is negative when the link never expires).
+func cacheControl(link *models.StoredLink, ttlSec int64) string {
+	permanent := link.RedirectType == http.StatusMovedPermanently || link.RedirectType == http.StatusPermanentRedirect
+	perRequest := len(link.Destinations) != 0 || len(link.Rules) != 0
+	if !permanent || perRequest || link.MaxClicks != 0 || link.IdleTTL != 0 || link.PasswordHash != "" {
+		return "no-store"
+	}
+
+	maxAge := int64(maxPermanentCacheAgeSec)
+	if ttlSec >= 0 && ttlSec < maxAge {
+		maxAge = ttlSec
+	}
+
+	return fmt.Sprintf("public, max-age=%d", maxAge)
 }
@@ -2,16 +2,25 @@ package link_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/apikey"
 	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
 	"github.com/pauloo27/shurl/internal/server/api/link"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/pauloo27/shurl/internal/server/core/password"
 	"github.com/stretchr/testify/assert"
 	"github.com/valkey-io/valkey-go"
 )
@@ -28,12 +37,25 @@ func TestRedirect(t *testing.T) {
 	setHello := vkey.B().Set().Key("link:localhost/hello").Value("http://example.com").Nx().Ex(30 * time.Second).Build()
 	setWorld := vkey.B().Set().Key("link:127.0.0.1/world").Value("http://example.com/world").Nx().Ex(30 * time.Second).Build()
 
+	setPermanent := vkey.B().Set().Key("link:localhost/permanent").
+		Value(`{"original_url":"http://example.com/permanent","redirect_type":308}`).
+		Nx().Ex(30 * time.Second).Build()
+	setForever := vkey.B().Set().Key("link:localhost/forever").
+		Value(`{"original_url":"http://example.com/forever","redirect_type":301}`).
+		Nx().Build()
+
 	mustDo(flushCmd)
 	mustDo(setHello)
 	mustDo(setWorld)
+	mustDo(setPermanent)
+	mustDo(setForever)
+
+	served := &config.Config{
+		Domains: map[string]*config.DomainConfig{"localhost": {}, "127.0.0.1": {}},
+	}
 
 	t.Run("Valid domain and slug pair", func(t *testing.T) {
-		cfg := &config.Config{}
+		cfg := served
 
 		rec, err := callRedirectHandler(cfg, vkey, "localhost", "hello")
 		assert.NoError(t, err)
@@ -41,8 +63,35 @@ func TestRedirect(t *testing.T) {
 		assert.Equal(t, "http://example.com", rec.Header().Get("Location"))
 	})
 
+	t.Run("Temporary redirect is not cached", func(t *testing.T) {
+		cfg := served
+
+		rec, err := callRedirectHandler(cfg, vkey, "localhost", "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Permanent redirect cached until expiration", func(t *testing.T) {
+		cfg := served
+
+		rec, err := callRedirectHandler(cfg, vkey, "localhost", "permanent")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, "http://example.com/permanent", rec.Header().Get("Location"))
+		assert.Equal(t, "public, max-age=30", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Permanent redirect without expiration", func(t *testing.T) {
+		cfg := served
+
+		rec, err := callRedirectHandler(cfg, vkey, "localhost", "forever")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "public, max-age=31536000", rec.Header().Get("Cache-Control"))
+	})
+
 	t.Run("Mismatched domain and slug pair", func(t *testing.T) {
-		cfg := &config.Config{}
+		cfg := served
 
 		rec, err := callRedirectHandler(cfg, vkey, "localhost", "world")
 		assert.NoError(t, err)
@@ -53,8 +102,60 @@ func TestRedirect(t *testing.T) {
 		)
 	})
 
+	t.Run("Unknown domain", func(t *testing.T) {
+		cfg := &config.Config{
+			Domains: map[string]*config.DomainConfig{
+				"127.0.0.1": {},
+			},
+		}
+
+		rec, err := callRedirectHandler(cfg, vkey, "localhost", "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMisdirectedRequest, rec.Code)
+	})
+
+	t.Run("Known domain with port", func(t *testing.T) {
+		cfg := &config.Config{
+			Domains: map[string]*config.DomainConfig{
+				"localhost": {},
+			},
+		}
+
+		rec, err := callRedirectHandler(cfg, vkey, "localhost:42069", "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "http://example.com", rec.Header().Get("Location"))
+	})
+
+	t.Run("Alias domain", func(t *testing.T) {
+		cfg := &config.Config{
+			Domains: map[string]*config.DomainConfig{
+				"localhost": {Aliases: []string{"sh.localhost"}},
+			},
+			DomainByAlias: map[string]string{"sh.localhost": "localhost"},
+		}
+
+		rec, err := callRedirectHandler(cfg, vkey, "sh.localhost", "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "http://example.com", rec.Header().Get("Location"))
+	})
+
+	t.Run("Slug not found with domain not found URL", func(t *testing.T) {
+		cfg := &config.Config{
+			Domains: map[string]*config.DomainConfig{
+				"localhost": {NotFoundURL: "https://example.com/404"},
+			},
+		}
+
+		rec, err := callRedirectHandler(cfg, vkey, "localhost", "slug")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/404", rec.Header().Get("Location"))
+	})
+
 	t.Run("Slug not found", func(t *testing.T) {
-		cfg := &config.Config{}
+		cfg := served
 
 		rec, err := callRedirectHandler(cfg, vkey, "localhost", "slug")
 		assert.NoError(t, err)
@@ -64,6 +165,21 @@ func TestRedirect(t *testing.T) {
 			strings.TrimSpace(rec.Body.String()),
 		)
 	})
+
+	t.Run("Host ignored without domains", func(t *testing.T) {
+		setKey(t, vkey, "link:default/shared", "http://example.com/shared", time.Minute)
+
+		for _, host := range []string{"localhost", "127.0.0.1", "attacker.example"} {
+			rec, err := callRedirectHandler(&config.Config{}, vkey, host, "shared")
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+			assert.Equal(t, "http://example.com/shared", rec.Header().Get("Location"))
+		}
+
+		rec, err := callRedirectHandler(&config.Config{}, vkey, "localhost", "hello")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
 }
 
 func callRedirectHandler(
@@ -80,7 +196,648 @@ func callRedirectHandler(
 	ctx.SetPath(path)
 	ctx.SetParamNames("slug")
 	ctx.SetParamValues(slug)
-	c := link.NewLinkController(cfg, vkey)
+	c := newLinkController(cfg, vkey)
 	err := c.Redirect(ctx)
 	return rec, err
 }
+
+func TestRedirectForwarding(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "plain", `{"original_url":"http://example.com/page?a=1"}`, 0)
+	setLink(t, vkey, "keep", `{"original_url":"http://example.com/page?a=1","forward_query":true,"query_conflict":"keep"}`, 0)
+	setLink(t, vkey, "override", `{"original_url":"http://example.com/page?a=1","forward_query":true,"query_conflict":"override"}`, 0)
+	setLink(t, vkey, "append", `{"original_url":"http://example.com/page?a=1","forward_query":true,"query_conflict":"append"}`, 0)
+	setLink(t, vkey, "path", `{"original_url":"http://example.com/docs/?a=1","forward_path":true}`, 0)
+	setLink(t, vkey, "ordered", `{"original_url":"http://example.com/page?z=1&sig=a%2Fb+c&a=1","forward_query":true,"query_conflict":"override"}`, 0)
+
+	cfg := &config.Config{}
+
+	cases := []struct {
+		target   string
+		status   int
+		location string
+	}{
+		{"/plain?a=2&ref=newsletter", http.StatusTemporaryRedirect, "http://example.com/page?a=1"},
+		{"/keep?a=2&ref=newsletter", http.StatusTemporaryRedirect, "http://example.com/page?a=1&ref=newsletter"},
+		{"/override?a=2&ref=newsletter", http.StatusTemporaryRedirect, "http://example.com/page?a=2&ref=newsletter"},
+		{"/append?a=2&ref=newsletter", http.StatusTemporaryRedirect, "http://example.com/page?a=1&a=2&ref=newsletter"},
+		{"/path/guides/intro", http.StatusTemporaryRedirect, "http://example.com/docs/guides/intro?a=1"},
+		{"/path/../../etc", http.StatusTemporaryRedirect, "http://example.com/docs/etc?a=1"},
+		{"/path/guides/", http.StatusTemporaryRedirect, "http://example.com/docs/guides/?a=1"},
+		{"/ordered?ref=news%20letter&a=2", http.StatusTemporaryRedirect, "http://example.com/page?z=1&sig=a%2Fb+c&a=2&ref=news+letter"},
+		{"/plain/extra/path", http.StatusNotFound, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.target, func(t *testing.T) {
+			rec := serveRedirect(cfg, vkey, "localhost", c.target)
+			assert.Equal(t, c.status, rec.Code)
+			assert.Equal(t, c.location, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectUTM(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "app", `{"original_url":"http://example.com/?utm_source=kept","app":"marketing"}`, 0)
+	setLink(t, vkey, "link", `{"original_url":"http://example.com/","app":"marketing","utm":{"campaign":"black-friday"}}`, 0)
+	setLink(t, vkey, "mailto", `{"original_url":"mailto:someone@example.com","app":"marketing"}`, 0)
+	setLink(t, vkey, "encoded", `{"original_url":"http://example.com/?z=1&sig=a%2Fb+c","app":"marketing"}`, 0)
+
+	cfg := &config.Config{
+		Apps: map[string]*config.AppConfig{
+			"marketing": {
+				UTM: &config.UTMConfig{Source: "shurl", Campaign: "default"},
+			},
+		},
+	}
+
+	cases := map[string]string{
+		"/app":    "http://example.com/?utm_source=kept&utm_campaign=default",
+		"/link":   "http://example.com/?utm_campaign=black-friday&utm_source=shurl",
+		"/mailto": "mailto:someone@example.com",
+		// the stored query is left as it is
+		"/encoded": "http://example.com/?z=1&sig=a%2Fb+c&utm_campaign=default&utm_source=shurl",
+	}
+
+	for target, location := range cases {
+		t.Run(target, func(t *testing.T) {
+			rec := serveRedirect(cfg, vkey, "localhost", target)
+			assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+			assert.Equal(t, location, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "preview", `{"original_url":"http://example.com/page","created_at":"2024-01-02T03:04:05Z"}`, time.Hour)
+
+	cfg := &config.Config{}
+
+	t.Run("JSON with plus suffix", func(t *testing.T) {
+		rec := serveRedirect(cfg, vkey, "localhost", "/preview+")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var preview models.LinkPreview
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+		assert.Equal(t, "preview", preview.Slug)
+		assert.Equal(t, "https://localhost/preview", preview.URL)
+		assert.Equal(t, "http://example.com/page", preview.Destination)
+		assert.Equal(t, "example.com", preview.DestinationDomain)
+		assert.Equal(t, "2024-01-02T03:04:05Z", preview.CreatedAt.Format(time.RFC3339))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *preview.ExpiresAt, 5*time.Second)
+	})
+
+	t.Run("HTML with preview query", func(t *testing.T) {
+		e := echo.New()
+		newLinkController(cfg, vkey).Route(e)
+
+		rec := serve(e, http.MethodGet, "/preview?preview=1", "", acceptHTML)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rec.Body.String(), `href="http://example.com/page"`)
+		assert.Contains(t, rec.Body.String(), "2024-01-02 03:04 UTC")
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		rec := serveRedirect(cfg, vkey, "localhost", "/missing+")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestPasswordProtected(t *testing.T) {
+	vkey := mockValkey()
+
+	hash, err := password.Hash("hunter2")
+	assert.NoError(t, err)
+
+	setLink(t, vkey, "secret", fmt.Sprintf(`{"original_url":"http://example.com/doc","password_hash":%q}`, hash), 0)
+	setLink(t, vkey, "permanent-secret",
+		fmt.Sprintf(`{"original_url":"http://example.com/doc","redirect_type":301,"password_hash":%q}`, hash), 0)
+
+	cfg := &config.Config{
+		Password: &config.PasswordConfig{CookieSecret: "secret", MaxFailures: 2},
+	}
+
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+
+	unlock := func(target, ip, pass string) *httptest.ResponseRecorder {
+		form := url.Values{"password": {pass}}
+		return serve(e, http.MethodPost, target, form.Encode(), map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"X-Real-IP":    ip,
+		})
+	}
+
+	t.Run("Locked for API clients", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/secret", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Preview is locked", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/secret+", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "example.com/doc")
+	})
+
+	t.Run("Form for browsers", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/secret", "", acceptHTML)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<form method="post">`)
+		assert.NotContains(t, rec.Body.String(), "example.com/doc")
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		rec := unlock("/secret", "10.0.0.1", "nope")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("Too many wrong passwords", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, unlock("/secret", "10.0.0.2", "nope").Code)
+		assert.Equal(t, http.StatusUnauthorized, unlock("/secret", "10.0.0.2", "nope").Code)
+		assert.Equal(t, http.StatusTooManyRequests, unlock("/secret", "10.0.0.2", "hunter2").Code)
+
+		// other IPs are not affected
+		assert.Equal(t, http.StatusSeeOther, unlock("/secret", "10.0.0.3", "hunter2").Code)
+	})
+
+	t.Run("Concurrent guesses", func(t *testing.T) {
+		var wg sync.WaitGroup
+		codes := make(chan int, 10)
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- unlock("/secret", "10.0.0.6", "nope").Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		guesses := 0
+		for code := range codes {
+			if code == http.StatusUnauthorized {
+				guesses++
+			}
+		}
+		assert.Equal(t, 2, guesses)
+	})
+
+	t.Run("Lockout without expiration", func(t *testing.T) {
+		key := "unlock_failures:default/secret/10.0.0.7"
+		setKey(t, vkey, key, "5", 0)
+
+		assert.Equal(t, http.StatusTooManyRequests, unlock("/secret", "10.0.0.7", "hunter2").Code)
+
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key(key).Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.Positive(t, ttl)
+	})
+
+	t.Run("Unlocked", func(t *testing.T) {
+		rec := unlock("/secret?a=1", "10.0.0.4", "hunter2")
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/secret?a=1", rec.Header().Get("Location"))
+
+		cookies := rec.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.True(t, cookies[0].HttpOnly)
+
+		rec = serve(e, http.MethodGet, "/secret", "", nil, cookies...)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "http://example.com/doc", rec.Header().Get("Location"))
+
+		rec = serve(e, http.MethodGet, "/secret+", "", nil, cookies...)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Unlocked permanent link isn't cached", func(t *testing.T) {
+		rec := unlock("/permanent-secret", "10.0.0.8", "hunter2")
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+		rec = serve(e, http.MethodGet, "/permanent-secret", "", nil, rec.Result().Cookies()...)
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "http://example.com/doc", rec.Header().Get("Location"))
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Tampered cookie", func(t *testing.T) {
+		rec := unlock("/secret", "10.0.0.5", "hunter2")
+		cookie := rec.Result().Cookies()[0]
+		cookie.Value = fmt.Sprintf("%d.%s", time.Now().Add(time.Hour*24*365).Unix(), strings.Split(cookie.Value, ".")[1])
+
+		rec = serve(e, http.MethodGet, "/secret", "", nil, cookie)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestRedirectMaxClicks(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "once", `{"original_url":"http://example.com/invite","redirect_type":308,"max_clicks":1}`, time.Hour)
+	setLink(t, vkey, "five", `{"original_url":"http://example.com/","max_clicks":5}`, time.Hour)
+
+	cfg := &config.Config{}
+
+	t.Run("One time", func(t *testing.T) {
+		rec := serveRedirect(cfg, vkey, "localhost", "/once+")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serveRedirect(cfg, vkey, "localhost", "/once")
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+		rec = serveRedirect(cfg, vkey, "localhost", "/once")
+		assert.Equal(t, http.StatusGone, rec.Code)
+	})
+
+	t.Run("Counter expires with the link", func(t *testing.T) {
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key("clicks:default/once").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Hour.Seconds(), ttl, 5)
+	})
+
+	t.Run("Concurrent clicks", func(t *testing.T) {
+		var wg sync.WaitGroup
+		codes := make(chan int, 20)
+
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- serveRedirect(cfg, vkey, "localhost", "/five").Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		count := map[int]int{}
+		for code := range codes {
+			count[code]++
+		}
+		assert.Equal(t, map[int]int{http.StatusTemporaryRedirect: 5, http.StatusGone: 15}, count)
+	})
+}
+
+func TestRedirectNotActive(t *testing.T) {
+	vkey := mockValkey()
+
+	notBefore := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	setLink(t, vkey, "launch",
+		fmt.Sprintf(`{"original_url":"http://example.com/launch","not_before":%q}`, notBefore.Format(time.RFC3339)), 0)
+	setLink(t, vkey, "launched", `{"original_url":"http://example.com/launched","not_before":"2020-01-01T00:00:00Z"}`, 0)
+
+	t.Run("Not active yet", func(t *testing.T) {
+		rec := serveRedirect(&config.Config{}, vkey, "localhost", "/launch")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, notBefore.Format(http.TimeFormat), rec.Header().Get("Retry-After"))
+		assert.Contains(t, rec.Body.String(), notBefore.Format(time.RFC3339))
+	})
+
+	t.Run("Not active URL", func(t *testing.T) {
+		cfg := &config.Config{
+			Domains: map[string]*config.DomainConfig{
+				"localhost": {NotActiveURL: "http://example.com/soon"},
+			},
+		}
+		setKey(t, vkey, "link:localhost/launch",
+			fmt.Sprintf(`{"original_url":"http://example.com/launch","not_before":%q}`, notBefore.Format(time.RFC3339)), 0)
+
+		rec := serveRedirect(cfg, vkey, "localhost", "/launch")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "http://example.com/soon", rec.Header().Get("Location"))
+	})
+
+	t.Run("Active", func(t *testing.T) {
+		rec := serveRedirect(&config.Config{}, vkey, "localhost", "/launched")
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	})
+
+	t.Run("Protected doesn't tell when", func(t *testing.T) {
+		hash, err := password.Hash("hunter2")
+		assert.NoError(t, err)
+
+		value := fmt.Sprintf(`{"original_url":"http://example.com/launch","not_before":%q,"password_hash":%q}`,
+			notBefore.Format(time.RFC3339), hash)
+		setLink(t, vkey, "secret-launch", value, 0)
+
+		rec := serveRedirect(&config.Config{}, vkey, "localhost", "/secret-launch")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+		assert.NotContains(t, rec.Body.String(), notBefore.Format(time.RFC3339))
+	})
+}
+
+func TestRedirectExpired(t *testing.T) {
+	vkey := mockValkey()
+
+	setKey(t, vkey, "tombstone:default/gone", `{"expired_at":"2024-01-01T00:00:00Z"}`, time.Hour)
+	setKey(t, vkey, "tombstone:default/fallback", `{"fallback_url":"http://example.com/link-fallback","app":"app","expired_at":"2024-01-01T00:00:00Z"}`, time.Hour)
+	setKey(t, vkey, "tombstone:default/app", `{"app":"app","expired_at":"2024-01-01T00:00:00Z"}`, time.Hour)
+
+	cfg := &config.Config{
+		Apps: map[string]*config.AppConfig{
+			"app": {FallbackURL: "http://example.com/app-fallback"},
+		},
+	}
+
+	cases := []struct {
+		target   string
+		status   int
+		location string
+	}{
+		{"/gone", http.StatusGone, ""},
+		{"/gone+", http.StatusGone, ""},
+		{"/fallback", http.StatusFound, "http://example.com/link-fallback"},
+		{"/app", http.StatusFound, "http://example.com/app-fallback"},
+		{"/never", http.StatusNotFound, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.target, func(t *testing.T) {
+			rec := serveRedirect(cfg, vkey, "localhost", c.target)
+			assert.Equal(t, c.status, rec.Code)
+			assert.Equal(t, c.location, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectIdle(t *testing.T) {
+	vkey := mockValkey()
+
+	maxExpiresAt := time.Now().Add(30 * time.Minute).UTC().Truncate(time.Second)
+
+	ttlOf := func(key string) int64 {
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key(key).Build()).AsInt64()
+		assert.NoError(t, err)
+		return ttl
+	}
+
+	setLink(t, vkey, "idle", `{"original_url":"http://example.com/","redirect_type":301,"idle_ttl":3600,"max_clicks":10}`, time.Minute)
+	setLink(t, vkey, "bounded", fmt.Sprintf(`{"original_url":"http://example.com/","idle_ttl":3600,"max_expires_at":%q}`, maxExpiresAt.Format(time.RFC3339)), time.Minute)
+
+	cfg := &config.Config{}
+
+	t.Run("Refreshed on use", func(t *testing.T) {
+		rec := serveRedirect(cfg, vkey, "localhost", "/idle")
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+		assert.InDelta(t, 3600, ttlOf("link:default/idle"), 5)
+		assert.InDelta(t, 3600, ttlOf("clicks:default/idle"), 5)
+	})
+
+	t.Run("Bounded by max expiration", func(t *testing.T) {
+		rec := serveRedirect(cfg, vkey, "localhost", "/bounded")
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+
+		assert.InDelta(t, 30*60, ttlOf("link:default/bounded"), 5)
+	})
+}
+
+func TestRedirectVariants(t *testing.T) {
+	vkey := mockValkey()
+
+	destinations := `[{"url":"http://a.example.com/","weight":1},{"url":"http://b.example.com/","weight":1}]`
+	setLink(t, vkey, "split", `{"original_url":"http://a.example.com/","app":"app","destinations":`+destinations+`}`, time.Hour)
+	setLink(t, vkey, "ip", `{"original_url":"http://a.example.com/","destinations":`+destinations+`,"sticky":"ip"}`, time.Hour)
+	setLink(t, vkey, "cookie", `{"original_url":"http://a.example.com/","destinations":`+destinations+`,"sticky":"cookie"}`, time.Hour)
+
+	app := &config.AppConfig{Name: "app", Enabled: true, APIKey: "key"}
+	other := &config.AppConfig{Name: "other", Enabled: true, APIKey: "other"}
+	cfg := &config.Config{
+		Apps:        map[string]*config.AppConfig{"app": app, "other": other},
+		AppByAPIKey: map[string]*config.AppConfig{apikey.Hash("key"): app, apikey.Hash("other"): other},
+	}
+
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+
+	t.Run("Split", func(t *testing.T) {
+		locations := map[string]int{}
+		for range 50 {
+			locations[serve(e, http.MethodGet, "/split", "", nil).Header().Get("Location")]++
+		}
+		assert.Len(t, locations, 2)
+		assert.Equal(t, 50, locations["http://a.example.com/"]+locations["http://b.example.com/"])
+	})
+
+	t.Run("Sticky by IP", func(t *testing.T) {
+		first := serve(e, http.MethodGet, "/ip", "", nil).Header().Get("Location")
+		for range 10 {
+			assert.Equal(t, first, serve(e, http.MethodGet, "/ip", "", nil).Header().Get("Location"))
+		}
+	})
+
+	t.Run("Sticky by cookie", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/cookie", "", nil)
+		cookies := rec.Result().Cookies()
+		assert.Len(t, cookies, 1)
+
+		first := rec.Header().Get("Location")
+		for range 10 {
+			assert.Equal(t, first, serve(e, http.MethodGet, "/cookie", "", nil, cookies...).Header().Get("Location"))
+		}
+
+		cookies[0].Value = "1"
+		assert.Equal(t, "http://b.example.com/", serve(e, http.MethodGet, "/cookie", "", nil, cookies...).Header().Get("Location"))
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/api/v1/links/split/stats", "", map[string]string{"X-API-Key": "key"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var stats models.LinkStats
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		assert.Equal(t, int64(50), stats.Clicks)
+		assert.Len(t, stats.Variants, 2)
+		assert.Equal(t, int64(50), stats.Variants[0].Clicks+stats.Variants[1].Clicks)
+		assert.Equal(t, "http://b.example.com/", stats.Variants[1].URL)
+	})
+
+	t.Run("Stats of other app", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(e, http.MethodGet, "/api/v1/links/split/stats", "", map[string]string{"X-API-Key": "other"}).Code)
+	})
+
+	t.Run("Stats without API key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(e, http.MethodGet, "/api/v1/links/split/stats", "", nil).Code)
+	})
+}
+
+func TestRedirectRules(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "app", `{"original_url":"http://example.com/","redirect_type":308,"rules":[
+		{"url":"https://apps.apple.com/app/id1","platforms":["ios"]},
+		{"url":"https://play.google.com/store/apps/details?id=app","platforms":["android"]},
+		{"url":"http://example.com/pt","languages":["pt"]}
+	]}`, 0)
+
+	e := echo.New()
+	newLinkController(&config.Config{}, vkey).Route(e)
+
+	cases := []struct {
+		name     string
+		ua       string
+		language string
+		location string
+	}{
+		{"iOS", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "", "https://apps.apple.com/app/id1"},
+		{"Android", "Mozilla/5.0 (Linux; Android 14; Pixel 8)", "pt-BR", "https://play.google.com/store/apps/details?id=app"},
+		{"Language", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "pt-BR,en;q=0.5", "http://example.com/pt"},
+		{"Default", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "en-US", "http://example.com/"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := serve(e, http.MethodGet, "/app", "", map[string]string{
+				"User-Agent":      c.ua,
+				"Accept-Language": c.language,
+			})
+
+			assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+			assert.Equal(t, c.location, rec.Header().Get("Location"))
+			assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestRedirectInterstitial(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "public", `{"original_url":"http://example.com/page","app":"public"}`, 0)
+	setLink(t, vkey, "trusted", `{"original_url":"http://example.com/page","app":"trusted"}`, 0)
+	setLink(t, vkey, "flagged", `{"original_url":"http://login.paypal-secure.com/","app":"trusted"}`, 0)
+
+	cfg := &config.Config{
+		Public: &config.AppConfig{Interstitial: true},
+		Apps:   map[string]*config.AppConfig{"trusted": {}},
+		Safety: &config.SafetyConfig{
+			Suspicious: &config.PolicyConfig{Block: config.DomainRules{Regex: []string{`^(.*\.)?paypa[l1]-`}}},
+		},
+	}
+
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+
+	t.Run("App with interstitial", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/public", "", acceptHTML)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.Contains(t, rec.Body.String(), `href="http://example.com/page"`)
+		assert.NotContains(t, rec.Body.String(), "<script")
+		assert.NotContains(t, rec.Body.String(), "suspicious")
+	})
+
+	t.Run("API clients are redirected", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/public", "", nil)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	})
+
+	t.Run("Trusted app", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/trusted", "", acceptHTML)
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	})
+
+	t.Run("Suspicious destination", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/flagged", "", acceptHTML)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "suspicious")
+		assert.Contains(t, rec.Body.String(), `href="http://login.paypal-secure.com/"`)
+	})
+}
+
+func TestRedirectErrorPages(t *testing.T) {
+	vkey := mockValkey()
+
+	for _, domain := range []string{"localhost", "branded.localhost"} {
+		setKey(t, vkey, "tombstone:"+domain+"/gone", `{"expired_at":"2024-01-01T00:00:00Z"}`, time.Hour)
+	}
+
+	gonePage := filepath.Join(t.TempDir(), "gone.html")
+	err := os.WriteFile(gonePage, []byte(`<p>branded {{ .Status }}: {{ .Message }}</p>`), 0o600)
+	assert.NoError(t, err)
+
+	cfg := &config.Config{
+		Domains: map[string]*config.DomainConfig{
+			"localhost": {},
+			"branded.localhost": {
+				GonePage: gonePage,
+			},
+		},
+	}
+
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+
+	branded := map[string]string{"Host": "branded.localhost", "Accept": acceptHTML["Accept"]}
+
+	t.Run("API clients get JSON", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/never", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+		assert.Contains(t, rec.Body.String(), "NOT_FOUND")
+	})
+
+	t.Run("Default not found page", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/never", "", acceptHTML)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rec.Body.String(), "Link not found")
+	})
+
+	t.Run("Default gone page", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/gone", "", acceptHTML)
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Link has expired")
+	})
+
+	t.Run("Custom gone page", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/gone", "", branded)
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Equal(t, "<p>branded 410: Link has expired</p>", rec.Body.String())
+	})
+
+	t.Run("Default page of a branded domain", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/never", "", branded)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Link not found")
+	})
+
+	t.Run("Unknown domain", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/never", "", map[string]string{"Host": "unknown.localhost", "Accept": acceptHTML["Accept"]})
+		assert.Equal(t, http.StatusMisdirectedRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Unknown domain")
+	})
+}
+
+func TestBrokenErrorPages(t *testing.T) {
+	vkey := mockValkey()
+
+	brokenPage := filepath.Join(t.TempDir(), "broken.html")
+	err := os.WriteFile(brokenPage, []byte(`<p>{{ .Status </p>`), 0o600)
+	assert.NoError(t, err)
+
+	pages := map[string]*config.DomainConfig{
+		"Missing page": {NotFoundPage: filepath.Join(t.TempDir(), "missing.html")},
+		"Broken page":  {GonePage: brokenPage},
+	}
+
+	for name, domainCfg := range pages {
+		t.Run(name, func(t *testing.T) {
+			cfg := &config.Config{Domains: map[string]*config.DomainConfig{"localhost": domainCfg}}
+			_, err := link.NewLinkController(cfg, vkey, apps.NewRegistry(cfg, vkey))
+			assert.ErrorContains(t, err, "domain localhost")
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	defaultQuarantineThreshold = 5
+	defaultReportsMaxPerIP     = 10
+	defaultReportsWindowSec    = 60 * 60
+
+	// older reports of a link are dropped
+	maxStoredReports = 100
+)
+
+// addReporterScript adds ARGV[1] to the reporters set of a link, expiring it
+// in ARGV[2] seconds (when positive), and returns if it was added along with
+// how many reporters the link has. It runs as a script so racing reports get
+// different counts.
+var addReporterScript = valkey.NewLuaScript(`
+local added = redis.call('SADD', KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('EXPIRE', KEYS[1], ttl)
+end
+
+return {added, redis.call('SCARD', KEYS[1])}
+`)
+
+type ReportLinkBody struct {
+	Slug    string `json:"slug" validate:"required,max=20"`
+	Domain  string `json:"domain" validate:"omitempty,max=253"`
+	Reason  string `json:"reason" validate:"required,oneof=phishing malware spam other"`
+	Details string `json:"details" validate:"omitempty,max=500"`
+}
+
+// reportsKey is a list with the latest reports of the link.
+func reportsKey(domain, slug string) string {
+	return fmt.Sprintf("reports:%s/%s", domain, slug)
+}
+
+// reportersKey is a set with the reporters of the link (see reporterID), so
+// the same visitor can't quarantine it alone.
+func reportersKey(domain, slug string) string {
+	return fmt.Sprintf("reporters:%s/%s", domain, slug)
+}
+
+func reportLimitKey(reporter string) string {
+	return fmt.Sprintf("report_limit:%s", reporter)
+}
+
+// reporterID identifies who sent a report. IPv6 addresses are grouped by
+// their /64 network, as a single visitor usually gets a whole one.
+func reporterID(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return addr.String()
+	}
+
+	return netip.PrefixFrom(addr.WithZone(""), 64).Masked().String()
+}
+
+// reportsSettings fills the missing report settings with the defaults.
+func reportsSettings(cfg *config.ReportsConfig) config.ReportsConfig {
+	var settings config.ReportsConfig
+	if cfg != nil {
+		settings = *cfg
+	}
+
+	if settings.QuarantineThreshold <= 0 {
+		settings.QuarantineThreshold = defaultQuarantineThreshold
+	}
+	if settings.MaxPerIP <= 0 {
+		settings.MaxPerIP = defaultReportsMaxPerIP
+	}
+	if settings.WindowSec <= 0 {
+		settings.WindowSec = defaultReportsWindowSec
+	}
+
+	return settings
+}
+
+// Report godoc
+//
+//	@Summary		Report an abusive link
+//	@Description	Report a link as phishing, malware, spam or other abuse.
+//	@Description	Once enough different visitors report it, the link is quarantined: it shows a warning instead of redirecting until an admin reviews it.
+//	@Description	Each IP can only send a few reports per hour.
+//	@Tags			link
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body	ReportLinkBody	true	"Domain is optional, the request host when empty"
+//	@Success		202		"Accepted"
+//	@Failure		400		{object}	api.BadRequestError			"Bad request"
+//	@Failure		404		{object}	api.NotFoundError			"Link not found"
+//	@Failure		421		{object}	api.UnknownDomainError		"Unknown domain"
+//	@Failure		422		{object}	api.ValidationError			"Validation error"
+//	@Failure		429		{object}	api.TooManyRequestsError	"Too many reports"
+//	@Failure		500		{object}	api.InternalServerError		"Internal server error"
+//	@Router			/reports [post]
+func (c *LinkController) Report(ctx echo.Context) error {
+	body, validationErr := validator.MustBindAndValidate[ReportLinkBody](ctx)
+	if validationErr != nil {
+		return ctx.JSON(api.DetailedError(validationErr.Error, validationErr.Details))
+	}
+
+	ip := ctx.RealIP()
+	reporter := reporterID(ip)
+
+	allowed, err := c.allowReport(ctx.Request().Context(), reporter)
+	if err != nil {
+		slog.Error("Failed to rate limit report", "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !allowed {
+		return ctx.JSON(api.Err(api.ErrTooManyRequests, "Too many reports, try again later"))
+	}
+
+	host := body.Domain
+	if host == "" {
+		host = ctx.Request().Host
+	}
+
+	domain, _, found := c.resolveDomain(host)
+	if !found {
+		return ctx.JSON(api.Err(api.ErrUnknownDomain, "Unknown domain"))
+	}
+
+	link, ttl, found, err := c.getLinkWithTTL(ctx.Request().Context(), domain, body.Slug)
+	if err != nil {
+		slog.Error("Failed to get link", "slug", body.Slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !found {
+		return ctx.JSON(api.Err(api.ErrNotFound, "Link not found"))
+	}
+
+	report, err := json.Marshal(models.Report{
+		Reason:     body.Reason,
+		Details:    body.Details,
+		ReportedAt: time.Now(),
+	})
+	if err != nil {
+		slog.Error("Failed to encode report", "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	added, reporters, err := c.recordReport(ctx.Request().Context(), domain, body.Slug, reporter, string(report), ttl)
+	if err != nil {
+		slog.Error("Failed to record report", "slug", body.Slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	slog.Info("Link reported", "domain", domain, "slug", body.Slug, "reason", body.Reason, "ip", ip)
+
+	// only the report reaching the threshold quarantines the link, the ones
+	// after it just retry when that failed
+	threshold := int64(c.reports.QuarantineThreshold)
+	if added && (reporters == threshold || (reporters > threshold && link.QuarantinedAt == nil)) {
+		if err := c.setQuarantined(ctx.Request().Context(), domain, body.Slug, link, true); err != nil {
+			slog.Error("Failed to quarantine link", "slug", body.Slug, "err", err)
+			return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+		}
+		slog.Warn("Link quarantined", "domain", domain, "slug", body.Slug, "reporters", reporters)
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// allowReport counts a report of the reporter, telling if it's still under
+// the limit. The window starts on the first report.
+func (c *LinkController) allowReport(ctx context.Context, reporter string) (bool, error) {
+	reports, err := c.countAttempt(ctx, reportLimitKey(reporter), c.reports.WindowSec)
+	if err != nil {
+		return false, err
+	}
+
+	return reports <= int64(c.reports.MaxPerIP), nil
+}
+
+// recordReport stores the report, returning if the reporter is a new one and
+// how many different reporters reported the link. The reporters are counted
+// atomically (see addReporterScript), while the list of reports is only kept
+// for the admin. Both expire with the link.
+func (c *LinkController) recordReport(
+	ctx context.Context, domain, slug, reporter, report string, ttlSec int64,
+) (bool, int64, error) {
+	reports := reportsKey(domain, slug)
+
+	cmds := valkey.Commands{
+		c.vkey.B().Rpush().Key(reports).Element(report).Build(),
+		c.vkey.B().Ltrim().Key(reports).Start(-maxStoredReports).Stop(-1).Build(),
+	}
+
+	if ttlSec > 0 {
+		cmds = append(cmds, c.vkey.B().Expire().Key(reports).Seconds(ttlSec).Build())
+	}
+
+	for _, res := range c.vkey.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			return false, 0, err
+		}
+	}
+
+	result, err := addReporterScript.Exec(
+		ctx, c.vkey, []string{reportersKey(domain, slug)}, []string{reporter, fmt.Sprint(ttlSec)},
+	).AsIntSlice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	return result[0] == 1, result[1], nil
+}
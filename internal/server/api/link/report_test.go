@@ -0,0 +1,197 @@
+package link_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportQuarantine(t *testing.T) {
+	vkey := mockValkey()
+
+	setKey(t, vkey, "link:localhost/phish", `{"original_url":"http://example.com/login"}`, 0)
+
+	cfg := &config.Config{
+		Reports:       &config.ReportsConfig{QuarantineThreshold: 2, MaxPerIP: 2},
+		Admin:         &config.AdminConfig{APIKey: "admin"},
+		Domains:       map[string]*config.DomainConfig{"localhost": {Aliases: []string{"short.localhost"}}},
+		DomainByAlias: map[string]string{"short.localhost": "localhost"},
+	}
+
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+
+	report := func(slug, ip string) *httptest.ResponseRecorder {
+		body := `{"slug":"` + slug + `","reason":"phishing","details":"asks for my bank password"}`
+		return serve(e, http.MethodPost, "/api/v1/reports", body, map[string]string{"X-Real-IP": ip})
+	}
+
+	admin := map[string]string{"X-Admin-Key": "admin"}
+
+	t.Run("Invalid reason", func(t *testing.T) {
+		rec := serve(e, http.MethodPost, "/api/v1/reports", `{"slug":"phish","reason":"boring"}`, nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Missing link", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, report("nope", "10.0.0.9").Code)
+	})
+
+	t.Run("Same IP doesn't quarantine alone", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, report("phish", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusAccepted, report("phish", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusTemporaryRedirect, serve(e, http.MethodGet, "/phish", "", nil).Code)
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		assert.Equal(t, http.StatusTooManyRequests, report("phish", "10.0.0.1").Code)
+	})
+
+	t.Run("Quarantined after threshold", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, report("phish", "10.0.0.2").Code)
+
+		rec := serve(e, http.MethodGet, "/phish", "", nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "QUARANTINED")
+
+		rec = serve(e, http.MethodGet, "/phish", "", map[string]string{"Accept": "text/html"})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "reported as abusive")
+		assert.NotContains(t, rec.Body.String(), "example.com/login")
+
+		assert.Equal(t, http.StatusForbidden, serve(e, http.MethodGet, "/phish+", "", nil).Code)
+	})
+
+	t.Run("Admin key required", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/api/v1/admin/quarantine", "", map[string]string{"X-Admin-Key": "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = serve(e, http.MethodDelete, "/api/v1/admin/quarantine/localhost/phish", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("List quarantined", func(t *testing.T) {
+		rec := serve(e, http.MethodGet, "/api/v1/admin/quarantine", "", admin)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var links []models.QuarantinedLink
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &links))
+		assert.Len(t, links, 1)
+		assert.Equal(t, "phish", links[0].Slug)
+		assert.Equal(t, "http://example.com/login", links[0].OriginalURL)
+		assert.Equal(t, int64(2), links[0].Reporters)
+		assert.Len(t, links[0].Reports, 3)
+		assert.Equal(t, "asks for my bank password", links[0].Reports[0].Details)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		rec := serve(e, http.MethodPost, "/api/v1/admin/quarantine/unknown.localhost/phish/restore", "", admin)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		// through the alias
+		rec = serve(e, http.MethodPost, "/api/v1/admin/quarantine/short.localhost/phish/restore", "", admin)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, serve(e, http.MethodGet, "/phish", "", nil).Code)
+
+		rec = serve(e, http.MethodPost, "/api/v1/admin/quarantine/localhost/phish/restore", "", admin)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = serve(e, http.MethodGet, "/api/v1/admin/quarantine", "", admin)
+		assert.JSONEq(t, "[]", rec.Body.String())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, report("phish", "10.0.0.3").Code)
+		assert.Equal(t, http.StatusAccepted, report("phish", "10.0.0.4").Code)
+		assert.Equal(t, http.StatusForbidden, serve(e, http.MethodGet, "/phish", "", nil).Code)
+
+		rec := serve(e, http.MethodDelete, "/api/v1/admin/quarantine/SHORT.localhost/phish", "", admin)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		// the slug stays taken, so it can't be registered again right away
+		assert.Equal(t, http.StatusGone, serve(e, http.MethodGet, "/phish", "", nil).Code)
+		ttl, err := vkey.Do(context.Background(), vkey.B().Ttl().Key("tombstone:localhost/phish").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.Greater(t, ttl, int64(24*60*60))
+
+		exists, err := vkey.Do(context.Background(), vkey.B().Exists().Key("reports:localhost/phish").Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.Zero(t, exists)
+	})
+}
+
+func TestReportFromIPv6(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "spam", `{"original_url":"http://example.com/offer"}`, 0)
+
+	cfg := &config.Config{
+		Reports: &config.ReportsConfig{QuarantineThreshold: 2, MaxPerIP: 2},
+	}
+
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+
+	report := func(ip string) int {
+		body := `{"slug":"spam","reason":"spam"}`
+		return serve(e, http.MethodPost, "/api/v1/reports", body, map[string]string{"X-Real-IP": ip}).Code
+	}
+
+	t.Run("Same network doesn't quarantine alone", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, report("2001:db8:0:1::1"))
+		assert.Equal(t, http.StatusAccepted, report("2001:db8:0:1::2"))
+		assert.Equal(t, http.StatusTemporaryRedirect, serve(e, http.MethodGet, "/spam", "", nil).Code)
+	})
+
+	t.Run("Same network is rate limited", func(t *testing.T) {
+		assert.Equal(t, http.StatusTooManyRequests, report("2001:db8:0:1:ffff::3"))
+	})
+
+	t.Run("Other network", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, report("2001:db8:0:2::1"))
+		assert.Equal(t, http.StatusForbidden, serve(e, http.MethodGet, "/spam", "", nil).Code)
+	})
+}
+
+func TestConcurrentReports(t *testing.T) {
+	vkey := mockValkey()
+
+	setLink(t, vkey, "phish", `{"original_url":"http://example.com/login"}`, 0)
+
+	cfg := &config.Config{
+		Reports: &config.ReportsConfig{QuarantineThreshold: 3},
+		Admin:   &config.AdminConfig{APIKey: "admin"},
+	}
+
+	e := echo.New()
+	newLinkController(cfg, vkey).Route(e)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := `{"slug":"phish","reason":"phishing"}`
+			rec := serve(e, http.MethodPost, "/api/v1/reports", body, map[string]string{"X-Real-IP": fmt.Sprintf("10.0.1.%d", i)})
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		}()
+	}
+	wg.Wait()
+
+	rec := serve(e, http.MethodGet, "/api/v1/admin/quarantine", "", map[string]string{"X-Admin-Key": "admin"})
+	var links []models.QuarantinedLink
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &links))
+	assert.Len(t, links, 1)
+	assert.Equal(t, int64(10), links[0].Reporters)
+	assert.Len(t, links[0].Reports, 10)
+}
@@ -0,0 +1,114 @@
+package link
+
+import (
+	"time"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+)
+
+const (
+	maxLinkDuration = 365 * 24 * time.Hour
+)
+
+// linkSchedule is when a link starts and stops redirecting, nil meaning right
+// away and never. Idle links expire IdleTTL after the last use, but never
+// after MaxExpiresAt.
+type linkSchedule struct {
+	NotBefore *time.Time
+	ExpiresAt *time.Time
+
+	IdleTTL      time.Duration
+	MaxExpiresAt *time.Time
+}
+
+// scheduleFromBody resolves the ttl (relative to now) or expires_at of the
+// body into an absolute schedule. Idle links without an absolute expiration
+// are bounded by the app max duration.
+func scheduleFromBody(
+	now time.Time, body *CreateLinkBody, app *config.AppConfig,
+) (*linkSchedule, *validator.ValidationError) {
+	schedule := &linkSchedule{NotBefore: body.NotBefore}
+
+	switch {
+	case body.ExpiresAt != nil:
+		expiresAt := body.ExpiresAt.Truncate(time.Second)
+		schedule.ExpiresAt = &expiresAt
+	case body.TTL != nil && *body.TTL != 0:
+		expiresAt := now.Add(time.Duration(*body.TTL) * time.Second).Truncate(time.Second)
+		schedule.ExpiresAt = &expiresAt
+	}
+
+	if schedule.NotBefore != nil {
+		notBefore := schedule.NotBefore.Truncate(time.Second)
+		schedule.NotBefore = &notBefore
+
+		if notBefore.Sub(now) > maxLinkDuration {
+			return nil, &validator.ValidationError{Field: "not_before", Error: "too_far"}
+		}
+	}
+
+	if schedule.ExpiresAt != nil {
+		if !schedule.ExpiresAt.After(now) {
+			return nil, &validator.ValidationError{Field: "expires_at", Error: "in_past"}
+		}
+
+		if schedule.NotBefore != nil && !schedule.NotBefore.Before(*schedule.ExpiresAt) {
+			return nil, &validator.ValidationError{Field: "not_before", Error: "after_expires_at"}
+		}
+
+		if schedule.activeDuration(now) > maxLinkDuration {
+			return nil, &validator.ValidationError{Field: "expires_at", Error: "too_far"}
+		}
+	}
+
+	if body.IdleTTL != 0 {
+		schedule.applyIdleTTL(now, time.Duration(body.IdleTTL)*time.Second, app)
+	}
+
+	return schedule, nil
+}
+
+func (s *linkSchedule) applyIdleTTL(now time.Time, idleTTL time.Duration, app *config.AppConfig) {
+	s.IdleTTL = idleTTL
+
+	start := s.start(now)
+
+	s.MaxExpiresAt = s.ExpiresAt
+	if s.MaxExpiresAt == nil && app.MaxDurationSec != 0 {
+		maxExpiresAt := start.Add(time.Duration(app.MaxDurationSec) * time.Second)
+		s.MaxExpiresAt = &maxExpiresAt
+	}
+
+	expiresAt := start.Add(idleTTL)
+	if s.MaxExpiresAt != nil && s.MaxExpiresAt.Before(expiresAt) {
+		expiresAt = *s.MaxExpiresAt
+	}
+	s.ExpiresAt = &expiresAt
+}
+
+func (s *linkSchedule) start(now time.Time) time.Time {
+	if s.NotBefore != nil && s.NotBefore.After(now) {
+		return *s.NotBefore
+	}
+	return now
+}
+
+// maxActiveDuration is like activeDuration, but for idle links that are
+// always used.
+func (s *linkSchedule) maxActiveDuration(now time.Time) time.Duration {
+	if s.MaxExpiresAt == nil {
+		return s.activeDuration(now)
+	}
+	return s.MaxExpiresAt.Sub(s.start(now))
+}
+
+// activeDuration is for how long the link redirects, 0 when it never
+// expires.
+func (s *linkSchedule) activeDuration(now time.Time) time.Duration {
+	if s.ExpiresAt == nil {
+		return 0
+	}
+
+	return s.ExpiresAt.Sub(s.start(now))
+}
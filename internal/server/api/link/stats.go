@@ -0,0 +1,123 @@
+package link
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	statsClicksField = "clicks"
+)
+
+// statsKey is a hash with the clicks of the link, and of each of its
+// variants.
+func statsKey(domain, slug string) string {
+	return fmt.Sprintf("stats:%s/%s", domain, slug)
+}
+
+func variantField(variant int) string {
+	return "variant:" + strconv.Itoa(variant)
+}
+
+// recordClick counts a redirect of the link, the stats expire with the link.
+func (c *LinkController) recordClick(ctx context.Context, domain, slug string, variant int, ttlSec int64) {
+	key := statsKey(domain, slug)
+
+	cmds := valkey.Commands{
+		c.vkey.B().Hincrby().Key(key).Field(statsClicksField).Increment(1).Build(),
+	}
+
+	if variant >= 0 {
+		cmds = append(cmds, c.vkey.B().Hincrby().Key(key).Field(variantField(variant)).Increment(1).Build())
+	}
+
+	if ttlSec > 0 {
+		cmds = append(cmds, c.vkey.B().Expire().Key(key).Seconds(ttlSec).Build())
+	}
+
+	for _, res := range c.vkey.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			slog.Error("Failed to record click", "slug", slug, "err", err)
+		}
+	}
+}
+
+// Stats godoc
+//
+//	@Summary		Get link stats
+//	@Description	Get how many times a link redirected, and to which of its destinations.
+//	@Description	Only the API Key that created the link can see its stats.
+//	@Tags			link
+//	@Produce		json
+//	@Param			slug		path		string	true	"Slug of the link"
+//	@Param			domain		query		string	false	"Domain of the link, the request host when empty"
+//	@Param			X-API-Key	header		string	true	"API Key that created the link"
+//	@Success		200			{object}	models.LinkStats
+//	@Failure		401			{object}	api.UnauthorizedError	"Missing or invalid API Key"
+//	@Failure		404			{object}	api.NotFoundError		"Link not found"
+//	@Failure		421			{object}	api.UnknownDomainError	"Unknown domain"
+//	@Failure		500			{object}	api.InternalServerError	"Internal server error"
+//	@Security		ApiKeyAuth
+//	@Router			/links/{slug}/stats [get]
+func (c *LinkController) Stats(ctx echo.Context) error {
+	slug := ctx.Param("slug")
+
+	apiKey := ctx.Request().Header.Get("X-API-Key")
+	app, key := c.apps.ByAPIKey(apiKey)
+	if apiKey == "" || app == nil || !app.Enabled {
+		return ctx.JSON(api.Err(api.ErrUnauthorized, "Invalid API key"))
+	}
+
+	slog.Debug("Getting link stats", "slug", slug, "app", app.Name, "key", keyLabel(key))
+
+	host := ctx.QueryParam("domain")
+	if host == "" {
+		host = ctx.Request().Host
+	}
+
+	domain, _, found := c.resolveDomain(host)
+	if !found {
+		return ctx.JSON(api.Err(api.ErrUnknownDomain, "Unknown domain"))
+	}
+
+	link, found, err := c.getLink(ctx.Request().Context(), domain, slug)
+	if err != nil {
+		slog.Error("Failed to get link", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	// links of other apps are not leaked
+	if !found || link.App != app.Name {
+		return ctx.JSON(api.Err(api.ErrNotFound, "Link not found"))
+	}
+
+	counts, err := c.vkey.Do(ctx.Request().Context(), c.vkey.B().Hgetall().Key(statsKey(domain, slug)).Build()).AsIntMap()
+	if err != nil {
+		slog.Error("Failed to get link stats", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	stats := models.LinkStats{
+		Slug:   slug,
+		Domain: domain,
+		Clicks: counts[statsClicksField],
+	}
+
+	for i, dest := range link.Destinations {
+		stats.Variants = append(stats.Variants, models.VariantStats{
+			URL:    dest.URL,
+			Weight: dest.Weight,
+			Clicks: counts[variantField(i)],
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, stats)
+}
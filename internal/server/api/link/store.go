@@ -0,0 +1,115 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/valkey-io/valkey-go"
+)
+
+func linkKey(domain, slug string) string {
+	return fmt.Sprintf("link:%s/%s", domain, slug)
+}
+
+func encodeLink(link *models.StoredLink) (string, error) {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeLink(value string) (*models.StoredLink, error) {
+	// legacy links, stored as the plain original URL
+	if !strings.HasPrefix(value, "{") {
+		return &models.StoredLink{
+			OriginalURL:  value,
+			RedirectType: http.StatusTemporaryRedirect,
+		}, nil
+	}
+
+	var link models.StoredLink
+	if err := json.Unmarshal([]byte(value), &link); err != nil {
+		return nil, err
+	}
+
+	if link.RedirectType == 0 {
+		link.RedirectType = http.StatusTemporaryRedirect
+	}
+
+	return &link, nil
+}
+
+func (c *LinkController) getLink(ctx context.Context, domain, slug string) (*models.StoredLink, bool, error) {
+	cmd := c.vkey.B().Get().Key(linkKey(domain, slug)).Build()
+	value, err := c.vkey.Do(ctx, cmd).ToString()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	link, err := decodeLink(value)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return link, true, nil
+}
+
+// getLinkWithTTL is like getLink, but also returns the remaining TTL of the
+// link in seconds (negative when it never expires).
+func (c *LinkController) getLinkWithTTL(ctx context.Context, domain, slug string) (*models.StoredLink, int64, bool, error) {
+	key := linkKey(domain, slug)
+
+	results := c.vkey.DoMulti(
+		ctx,
+		c.vkey.B().Get().Key(key).Build(),
+		c.vkey.B().Ttl().Key(key).Build(),
+	)
+
+	value, err := results[0].ToString()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return nil, 0, false, nil
+		}
+		return nil, 0, false, err
+	}
+
+	link, err := decodeLink(value)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	ttl, err := results[1].AsInt64()
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	return link, ttl, true, nil
+}
+
+func (c *LinkController) lookupLink(ctx context.Context, domain, slug string) ([]string, bool, error) {
+	link, found, err := c.getLink(ctx, domain, slug)
+	if err != nil || !found {
+		return nil, found, err
+	}
+
+	urls := []string{link.OriginalURL}
+	for _, dest := range link.Destinations {
+		urls = append(urls, dest.URL)
+	}
+	for _, rule := range link.Rules {
+		urls = append(urls, rule.URL)
+	}
+	if link.FallbackURL != "" {
+		urls = append(urls, link.FallbackURL)
+	}
+
+	return urls, true, nil
+}
@@ -0,0 +1,89 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/valkey-io/valkey-go"
+)
+
+func tombstoneKey(domain, slug string) string {
+	return fmt.Sprintf("tombstone:%s/%s", domain, slug)
+}
+
+// setTombstone keeps the slug taken until the grace period after the link
+// expiration is over.
+func (c *LinkController) setTombstone(
+	ctx context.Context, domain, slug string, tombstone *models.Tombstone, grace time.Duration,
+) error {
+	data, err := json.Marshal(tombstone)
+	if err != nil {
+		return err
+	}
+
+	cmd := c.vkey.B().Set().Key(tombstoneKey(domain, slug)).Value(string(data)).
+		Exat(tombstone.ExpiredAt.Add(grace)).Build()
+	return c.vkey.Do(ctx, cmd).Error()
+}
+
+func (c *LinkController) hasTombstone(ctx context.Context, domain, slug string) (bool, error) {
+	count, err := c.vkey.Do(ctx, c.vkey.B().Exists().Key(tombstoneKey(domain, slug)).Build()).AsInt64()
+	if err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
+func (c *LinkController) getTombstone(ctx context.Context, domain, slug string) (*models.Tombstone, bool, error) {
+	value, err := c.vkey.Do(ctx, c.vkey.B().Get().Key(tombstoneKey(domain, slug)).Build()).ToString()
+	if err != nil {
+		if valkey.IsValkeyNil(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var tombstone models.Tombstone
+	if err := json.Unmarshal([]byte(value), &tombstone); err != nil {
+		return nil, false, err
+	}
+
+	return &tombstone, true, nil
+}
+
+// linkNotFound answers requests to missing links, telling apart the ones
+// that expired recently from the ones that never existed.
+func (c *LinkController) linkNotFound(
+	ctx echo.Context, domain string, domainCfg *config.DomainConfig, slug string,
+) error {
+	tombstone, found, err := c.getTombstone(ctx.Request().Context(), domain, slug)
+	if err != nil {
+		slog.Error("Failed to get tombstone", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !found {
+		return c.notFound(ctx, domainCfg)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	fallbackURL := tombstone.FallbackURL
+	if app := c.appByName(tombstone.App); fallbackURL == "" && app != nil {
+		fallbackURL = app.FallbackURL
+	}
+
+	if fallbackURL != "" {
+		return ctx.Redirect(http.StatusFound, fallbackURL)
+	}
+
+	return c.errorResponse(ctx, domainCfg, api.ErrGone, "Link has expired")
+}
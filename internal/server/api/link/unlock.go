@@ -0,0 +1,210 @@
+package link
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/api"
+	"github.com/pauloo27/shurl/internal/server/core/password"
+	"github.com/pauloo27/shurl/internal/server/pages"
+)
+
+const (
+	defaultUnlockCookieTTLSec     = 60 * 60
+	defaultUnlockMaxFailures      = 5
+	defaultUnlockFailureWindowSec = 15 * 60
+)
+
+type passwordPage struct {
+	URL   string
+	Error string
+}
+
+func unlockFailuresKey(domain, slug, ip string) string {
+	return fmt.Sprintf("unlock_failures:%s/%s/%s", domain, slug, ip)
+}
+
+// unlockCookieName is unique per slug, so the cookie can be sent to the link,
+// its preview (/slug+) and its forwarded paths.
+func unlockCookieName(slug string) string {
+	return "shurl_unlock_" + base64.RawURLEncoding.EncodeToString([]byte(slug))
+}
+
+// passwordSettings fills the missing password settings with the defaults,
+// generating a random cookie secret if needed.
+func passwordSettings(cfg *config.PasswordConfig) config.PasswordConfig {
+	var settings config.PasswordConfig
+	if cfg != nil {
+		settings = *cfg
+	}
+
+	if settings.CookieSecret == "" {
+		slog.Warn("No password cookie secret set, using a random one. Unlocked links are forgotten on restart")
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+		settings.CookieSecret = string(secret)
+	}
+
+	if settings.CookieTTLSec <= 0 {
+		settings.CookieTTLSec = defaultUnlockCookieTTLSec
+	}
+	if settings.MaxFailures <= 0 {
+		settings.MaxFailures = defaultUnlockMaxFailures
+	}
+	if settings.FailureWindowSec <= 0 {
+		settings.FailureWindowSec = defaultUnlockFailureWindowSec
+	}
+
+	return settings
+}
+
+// unlockToken signs the link and the cookie expiration. The password hash is
+// part of the signature, so changing the password locks the link again.
+func (c *LinkController) unlockToken(domain, slug string, link *models.StoredLink, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(c.password.CookieSecret))
+	fmt.Fprintf(mac, "%s/%s|%d|%s", domain, slug, expiresAt, link.PasswordHash)
+	return fmt.Sprintf("%d.%s", expiresAt, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func (c *LinkController) isUnlocked(ctx echo.Context, domain, slug string, link *models.StoredLink) bool {
+	if link.PasswordHash == "" {
+		return true
+	}
+
+	cookie, err := ctx.Cookie(unlockCookieName(slug))
+	if err != nil {
+		return false
+	}
+
+	rawExpiresAt, _, found := strings.Cut(cookie.Value, ".")
+	if !found {
+		return false
+	}
+
+	expiresAt, err := strconv.ParseInt(rawExpiresAt, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+
+	expected := c.unlockToken(domain, slug, link, expiresAt)
+	return hmac.Equal([]byte(cookie.Value), []byte(expected))
+}
+
+// locked asks browsers for the link password, API clients get an error
+// instead.
+func locked(
+	ctx echo.Context, errType api.ErrorType, domainCfg *config.DomainConfig, domain, slug, message string,
+) error {
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	if pages.AcceptsHTML(ctx.Request()) {
+		return pages.Render(ctx, errType.StatusCode, "password", passwordPage{
+			URL:   linkURL(ctx, domainCfg, domain, slug),
+			Error: message,
+		})
+	}
+
+	if message == "" {
+		message = "Link is password protected"
+	}
+	return ctx.JSON(api.Err(errType, message))
+}
+
+// Unlock godoc
+//
+//	@Summary		Unlock a password protected link
+//	@Description	Check the password of a protected link, the form shown when redirecting posts to it.
+//	@Description	When the password is right, a cookie remembering it is set and the client is sent back to the link.
+//	@Description	Too many wrong passwords from the same IP are rate limited.
+//	@Tags			link
+//	@Accept			x-www-form-urlencoded
+//	@Param			slug		path		string	true	"Slug of the link"
+//	@Param			password	formData	string	true	"Password of the link"
+//	@Success		303			"See other, back to the link"
+//	@Failure		401			{object}	api.UnauthorizedError		"Wrong password"
+//	@Failure		404			{object}	api.NotFoundError			"Link not found"
+//	@Failure		421			{object}	api.UnknownDomainError		"Unknown domain"
+//	@Failure		429			{object}	api.TooManyRequestsError	"Too many wrong passwords"
+//	@Failure		500			{object}	api.InternalServerError		"Internal server error"
+//	@Router			/{slug} [post]
+func (c *LinkController) Unlock(ctx echo.Context) error {
+	// the form is also shown in the preview (/slug+)
+	slug := strings.TrimSuffix(ctx.Param("slug"), "+")
+
+	domain, domainCfg, found := c.resolveDomain(ctx.Request().Host)
+	if !found {
+		return ctx.JSON(api.Err(api.ErrUnknownDomain, "Unknown domain"))
+	}
+
+	link, found, err := c.getLink(ctx.Request().Context(), domain, slug)
+	if err != nil {
+		slog.Error("Failed to get link", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !found {
+		return c.notFound(ctx, domainCfg)
+	}
+
+	back := ctx.Request().URL.RequestURI()
+	if link.PasswordHash == "" {
+		return ctx.Redirect(http.StatusSeeOther, back)
+	}
+
+	// counted before checking the password, so concurrent guesses can't go
+	// over the limit. A right password clears the count.
+	failuresKey := unlockFailuresKey(domain, slug, ctx.RealIP())
+	attempts, err := c.countAttempt(ctx.Request().Context(), failuresKey, c.password.FailureWindowSec)
+	if err != nil {
+		slog.Error("Failed to count unlock attempt", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if attempts > int64(c.password.MaxFailures) {
+		return locked(ctx, api.ErrTooManyRequests, domainCfg, domain, slug, "Too many wrong passwords, try again later")
+	}
+
+	ok, err := password.Verify(ctx.FormValue("password"), link.PasswordHash)
+	if err != nil {
+		slog.Error("Failed to verify password", "slug", slug, "err", err)
+		return ctx.JSON(api.Err(api.ErrInternalServer, "Something went wrong"))
+	}
+
+	if !ok {
+		slog.Info("Wrong link password", "domain", domain, "slug", slug, "ip", ctx.RealIP())
+		return locked(ctx, api.ErrUnauthorized, domainCfg, domain, slug, "Wrong password")
+	}
+
+	if err := c.vkey.Do(ctx.Request().Context(), c.vkey.B().Del().Key(failuresKey).Build()).Error(); err != nil {
+		slog.Error("Failed to clear unlock failures", "slug", slug, "err", err)
+	}
+
+	ttl := time.Duration(c.password.CookieTTLSec) * time.Second
+	expiresAt := time.Now().Add(ttl)
+
+	ctx.SetCookie(&http.Cookie{
+		Name:     unlockCookieName(slug),
+		Value:    c.unlockToken(domain, slug, link, expiresAt.Unix()),
+		Path:     "/",
+		Expires:  expiresAt,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   linkScheme(ctx, domainCfg) == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return ctx.Redirect(http.StatusSeeOther, back)
+}
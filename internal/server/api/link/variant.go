@@ -0,0 +1,94 @@
+package link
+
+import (
+	"encoding/base64"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/models"
+)
+
+const (
+	stickyCookie = "cookie"
+	stickyIP     = "ip"
+
+	variantCookieMaxAge = 30 * 24 * time.Hour
+)
+
+func variantCookieName(slug string) string {
+	return "shurl_variant_" + base64.RawURLEncoding.EncodeToString([]byte(slug))
+}
+
+// weightedIndex finds the destination where n (from 0 to the sum of the
+// weights) falls.
+func weightedIndex(destinations []models.Destination, n int) int {
+	for i, dest := range destinations {
+		if n < dest.Weight {
+			return i
+		}
+		n -= dest.Weight
+	}
+	return len(destinations) - 1
+}
+
+func totalWeight(destinations []models.Destination) int {
+	total := 0
+	for _, dest := range destinations {
+		total += dest.Weight
+	}
+	return total
+}
+
+// pickVariant chooses the destination of the request, -1 when the link has a
+// single one. Sticky links send the same visitor to the same destination.
+func pickVariant(
+	ctx echo.Context, domainCfg *config.DomainConfig, domain, slug string, link *models.StoredLink,
+) int {
+	if len(link.Destinations) == 0 {
+		return -1
+	}
+
+	total := totalWeight(link.Destinations)
+
+	switch link.Sticky {
+	case stickyIP:
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(domain + "/" + slug + "|" + ctx.RealIP()))
+		return weightedIndex(link.Destinations, int(hash.Sum32()%uint32(total)))
+	case stickyCookie:
+		if cookie, err := ctx.Cookie(variantCookieName(slug)); err == nil {
+			if i, err := strconv.Atoi(cookie.Value); err == nil && i >= 0 && i < len(link.Destinations) {
+				return i
+			}
+		}
+
+		/* #nosec G404 */
+		i := weightedIndex(link.Destinations, rand.IntN(total))
+		ctx.SetCookie(&http.Cookie{
+			Name:     variantCookieName(slug),
+			Value:    strconv.Itoa(i),
+			Path:     "/",
+			MaxAge:   int(variantCookieMaxAge.Seconds()),
+			HttpOnly: true,
+			Secure:   linkScheme(ctx, domainCfg) == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+		return i
+	default:
+		/* #nosec G404 */
+		return weightedIndex(link.Destinations, rand.IntN(total))
+	}
+}
+
+// withOriginalURL is a copy of the link going somewhere else, such as one of
+// its variants.
+func withOriginalURL(link *models.StoredLink, originalURL string) *models.StoredLink {
+	copied := *link
+	copied.OriginalURL = originalURL
+	return &copied
+}
@@ -0,0 +1,21 @@
+package admin
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/pauloo27/shurl/internal/config"
+)
+
+const (
+	HeaderAdminKey = "X-Admin-Key"
+)
+
+// IsAdmin checks the admin key of the request, the admin API is disabled
+// when no key is configured.
+func IsAdmin(ctx echo.Context, cfg *config.AdminConfig) bool {
+	if cfg == nil || cfg.APIKeyDigest() == "" {
+		return false
+	}
+
+	return apikey.Matches(ctx.Request().Header.Get(HeaderAdminKey), cfg.APIKeyDigest())
+}
@@ -0,0 +1,373 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/valkey-io/valkey-go"
+)
+
+const (
+	// hash with the apps created at runtime, by name
+	appsKey = "apps"
+	// hash with the name of the runtime app owning each API key hash. The
+	// tag keeps it in the slot of appsKey, so both change in one script
+	apiKeysKey = "{apps}:keys"
+	// replicas reload the apps when anything is published here
+	changesChannel = "apps:changed"
+
+	watchRetryDelay = time.Second
+)
+
+// saveAppScript creates (ARGV[2] is "create"), updates ("update") or deletes
+// ("delete") the app named ARGV[1], stored as ARGV[3]. The API key hashes in
+// ARGV[4:] are claimed for the app, failing when another one owns them, and
+// the ones it no longer uses are released.
+var saveAppScript = valkey.NewLuaScript(`
+local name, action = ARGV[1], ARGV[2]
+
+local exists = redis.call('HEXISTS', KEYS[1], name) == 1
+if action == 'create' and exists then
+	return 'exists'
+end
+if action ~= 'create' and not exists then
+	return 'not_found'
+end
+
+for i = 4, #ARGV do
+	local owner = redis.call('HGET', KEYS[2], ARGV[i])
+	if owner and owner ~= name then
+		return 'api_key_used'
+	end
+end
+
+local owners = redis.call('HGETALL', KEYS[2])
+for i = 1, #owners, 2 do
+	if owners[i + 1] == name then
+		redis.call('HDEL', KEYS[2], owners[i])
+	end
+end
+
+if action == 'delete' then
+	redis.call('HDEL', KEYS[1], name)
+	return 'ok'
+end
+
+redis.call('HSET', KEYS[1], name, ARGV[3])
+for i = 4, #ARGV do
+	redis.call('HSET', KEYS[2], ARGV[i], name)
+end
+
+return 'ok'
+`)
+
+var (
+	ErrNotFound   = errors.New("app not found")
+	ErrExists     = errors.New("app already exists")
+	ErrFromConfig = errors.New("app is defined in the config")
+	ErrAPIKeyUsed = errors.New("api key already used")
+)
+
+// Registry finds apps by name or API key, both the ones in the config and the
+// ones created at runtime (stored in valkey). Runtime apps are cached in
+// memory and reloaded when any replica changes them.
+type Registry struct {
+	cfg  *config.Config
+	vkey valkey.Client
+
+	mu     sync.RWMutex
+	byName map[string]*config.AppConfig
+	// keyed by the API key hash
+	byAPIKey map[string]*config.AppConfig
+}
+
+func NewRegistry(cfg *config.Config, vkey valkey.Client) *Registry {
+	return &Registry{
+		cfg:      cfg,
+		vkey:     vkey,
+		byName:   make(map[string]*config.AppConfig),
+		byAPIKey: make(map[string]*config.AppConfig),
+	}
+}
+
+// ByName finds an app by its name, the public app included.
+func (r *Registry) ByName(name string) *config.AppConfig {
+	if name == config.PublicAppName {
+		return r.cfg.Public
+	}
+
+	if app, found := r.cfg.Apps[name]; found {
+		return app
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byName[name]
+}
+
+// ByAPIKey finds an app by the hash of one of its API keys, only the hashes
+// are kept in memory. Keys past their notAfter are not accepted.
+func (r *Registry) ByAPIKey(key string) (*config.AppConfig, *config.APIKeyConfig) {
+	app := r.byDigest(apikey.Hash(key))
+	if app == nil {
+		return nil, nil
+	}
+
+	for _, appKey := range app.Keys() {
+		if !apikey.Matches(key, appKey.Digest()) {
+			continue
+		}
+
+		if !appKey.Active(time.Now()) {
+			slog.Warn("Expired API key used", "app", app.Name, "key", appKey.Label)
+			return nil, nil
+		}
+
+		return app, &appKey
+	}
+
+	return nil, nil
+}
+
+func (r *Registry) byDigest(digest string) *config.AppConfig {
+	if app, found := r.cfg.AppByAPIKey[digest]; found {
+		return app
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byAPIKey[digest]
+}
+
+// FromConfig tells if the app is defined in the config, those can't be
+// changed at runtime.
+func (r *Registry) FromConfig(name string) bool {
+	_, found := r.cfg.Apps[name]
+	return found || name == config.PublicAppName
+}
+
+// List returns the apps, but the public one, sorted by name.
+func (r *Registry) List() []*config.AppConfig {
+	r.mu.RLock()
+	apps := make([]*config.AppConfig, 0, len(r.cfg.Apps)+len(r.byName))
+	for _, app := range r.byName {
+		apps = append(apps, app)
+	}
+	r.mu.RUnlock()
+
+	for name, app := range r.cfg.Apps {
+		// the name is only set by the config loader
+		named := *app
+		named.Name = name
+		apps = append(apps, &named)
+	}
+
+	slices.SortFunc(apps, func(a, b *config.AppConfig) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return apps
+}
+
+// Load reads the runtime apps from valkey, replacing the cached ones.
+func (r *Registry) Load(ctx context.Context) error {
+	values, err := r.vkey.Do(ctx, r.vkey.B().Hgetall().Key(appsKey).Build()).AsStrMap()
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*config.AppConfig, len(values))
+	byAPIKey := make(map[string]*config.AppConfig, len(values))
+
+	for name, value := range values {
+		var app config.AppConfig
+		if err := json.Unmarshal([]byte(value), &app); err != nil {
+			slog.Error("Failed to decode app, ignoring it", "app", name, "err", err)
+			continue
+		}
+
+		app.Name = name
+		byName[name] = &app
+		for _, key := range app.Keys() {
+			byAPIKey[key.Digest()] = &app
+		}
+	}
+
+	r.mu.Lock()
+	r.byName, r.byAPIKey = byName, byAPIKey
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the runtime apps when they change, until the context is done.
+func (r *Registry) Watch(ctx context.Context) {
+	reload := make(chan struct{}, 1)
+	requestReload := func() {
+		select {
+		case reload <- struct{}{}:
+		default:
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				if err := r.Load(ctx); err != nil {
+					slog.Error("Failed to reload apps", "err", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		// the handler must not block, changes in a row are merged in a
+		// single reload
+		err := r.vkey.Receive(ctx, r.vkey.B().Subscribe().Channel(changesChannel).Build(), func(valkey.PubSubMessage) {
+			requestReload()
+		})
+
+		if ctx.Err() != nil || errors.Is(err, valkey.ErrClosing) {
+			return
+		}
+
+		slog.Error("Lost the apps subscription, retrying", "err", err)
+
+		retry := time.NewTimer(watchRetryDelay)
+		select {
+		case <-ctx.Done():
+			retry.Stop()
+			return
+		case <-retry.C:
+		}
+
+		// changes may have been missed in the meanwhile
+		requestReload()
+	}
+}
+
+// Create stores a new runtime app, its name and API key must not be in use.
+// Only the hash of the API key is stored, the plain one is removed from the
+// app.
+func (r *Registry) Create(ctx context.Context, app *config.AppConfig) error {
+	if r.FromConfig(app.Name) {
+		return ErrExists
+	}
+
+	if err := r.checkAPIKey(app); err != nil {
+		return err
+	}
+
+	return r.save(ctx, "create", app)
+}
+
+// Update replaces the settings of a runtime app.
+func (r *Registry) Update(ctx context.Context, app *config.AppConfig) error {
+	if r.FromConfig(app.Name) {
+		return ErrFromConfig
+	}
+
+	if err := r.checkAPIKey(app); err != nil {
+		return err
+	}
+
+	return r.save(ctx, "update", app)
+}
+
+func (r *Registry) Delete(ctx context.Context, name string) error {
+	if r.FromConfig(name) {
+		return ErrFromConfig
+	}
+
+	return r.save(ctx, "delete", &config.AppConfig{Name: name})
+}
+
+// save runs saveAppScript for the app, so its API keys are checked against
+// the other runtime apps in the same step they are stored.
+func (r *Registry) save(ctx context.Context, action string, app *config.AppConfig) error {
+	args := []string{app.Name, action, ""}
+
+	if action != "delete" {
+		value, err := encodeApp(app)
+		if err != nil {
+			return err
+		}
+		args[2] = value
+
+		for _, key := range app.Keys() {
+			args = append(args, key.Digest())
+		}
+	}
+
+	result, err := saveAppScript.Exec(ctx, r.vkey, []string{appsKey, apiKeysKey}, args).ToString()
+	if err != nil {
+		return err
+	}
+
+	switch result {
+	case "exists":
+		return ErrExists
+	case "not_found":
+		return ErrNotFound
+	case "api_key_used":
+		return ErrAPIKeyUsed
+	}
+
+	return r.changed(ctx)
+}
+
+// checkAPIKey tells if the keys of the app are used by the admin or by an app
+// in the config, the runtime apps are checked by saveAppScript.
+func (r *Registry) checkAPIKey(app *config.AppConfig) error {
+	for _, key := range app.Keys() {
+		digest := key.Digest()
+
+		if r.cfg.Admin != nil && digest == r.cfg.Admin.APIKeyDigest() {
+			return ErrAPIKeyUsed
+		}
+
+		if _, found := r.cfg.AppByAPIKey[digest]; found {
+			return ErrAPIKeyUsed
+		}
+	}
+
+	return nil
+}
+
+func encodeApp(app *config.AppConfig) (string, error) {
+	app.APIKeyHash, app.APIKey = app.APIKeyDigest(), ""
+	// the keys may be shared with the cached app
+	keys := make([]config.APIKeyConfig, len(app.APIKeys))
+	for i, key := range app.APIKeys {
+		key.KeyHash, key.Key = key.Digest(), ""
+		keys[i] = key
+	}
+	app.APIKeys = keys
+
+	value, err := json.Marshal(app)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// changed reloads the apps of this replica right away, and tells the other
+// ones to do the same.
+func (r *Registry) changed(ctx context.Context) error {
+	if err := r.Load(ctx); err != nil {
+		return err
+	}
+
+	return r.vkey.Do(ctx, r.vkey.B().Publish().Channel(changesChannel).Message(time.Now().UTC().Format(time.RFC3339Nano)).Build()).Error()
+}
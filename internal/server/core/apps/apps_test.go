@@ -0,0 +1,209 @@
+package apps_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pauloo27/shurl/internal/apikey"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/apps"
+	"github.com/stretchr/testify/assert"
+	"github.com/valkey-io/valkey-go"
+)
+
+func mockValkey(t *testing.T, s *miniredis.Miniredis) valkey.Client {
+	client, err := valkey.NewClient(valkey.ClientOption{
+		InitAddress:  []string{s.Addr()},
+		DisableCache: true,
+		// miniredis doesn't run commands on subscribed RESP3 connections,
+		// with RESP2 subscriptions get a dedicated one
+		AlwaysRESP2: true,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func appByKey(registry *apps.Registry, key string) *config.AppConfig {
+	app, _ := registry.ByAPIKey(key)
+	return app
+}
+
+func TestRegistry(t *testing.T) {
+	s := miniredis.RunT(t)
+	vkey := mockValkey(t, s)
+
+	static := &config.AppConfig{Name: "static", Enabled: true, APIKey: "static-key"}
+	cfg := &config.Config{
+		Public:      &config.AppConfig{Enabled: true},
+		Apps:        map[string]*config.AppConfig{"static": static},
+		AppByAPIKey: map[string]*config.AppConfig{apikey.Hash("static-key"): static},
+		Admin:       &config.AdminConfig{APIKey: "admin-key"},
+	}
+
+	registry := apps.NewRegistry(cfg, vkey)
+	ctx := context.Background()
+
+	assert.Equal(t, cfg.Public, registry.ByName(config.PublicAppName))
+	assert.Equal(t, static, registry.ByName("static"))
+	assert.Equal(t, static, appByKey(registry, "static-key"))
+	assert.True(t, registry.FromConfig("static"))
+	assert.True(t, registry.FromConfig(config.PublicAppName))
+
+	t.Run("Create", func(t *testing.T) {
+		err := registry.Create(ctx, &config.AppConfig{Name: "team", Enabled: true, APIKey: "team-key"})
+		assert.NoError(t, err)
+
+		app := appByKey(registry, "team-key")
+		assert.NotNil(t, app)
+		assert.Equal(t, "team", app.Name)
+		assert.False(t, registry.FromConfig("team"))
+		assert.Empty(t, app.APIKey)
+
+		stored, err := vkey.Do(ctx, vkey.B().Hget().Key("apps").Field("team").Build()).ToString()
+		assert.NoError(t, err)
+		assert.NotContains(t, stored, "team-key")
+		assert.Contains(t, stored, apikey.Hash("team-key"))
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		assert.ErrorIs(t, registry.Create(ctx, &config.AppConfig{Name: "team", APIKey: "other"}), apps.ErrExists)
+		assert.ErrorIs(t, registry.Create(ctx, &config.AppConfig{Name: "static", APIKey: "other"}), apps.ErrExists)
+		assert.ErrorIs(t, registry.Create(ctx, &config.AppConfig{Name: "public", APIKey: "other"}), apps.ErrExists)
+		assert.ErrorIs(t, registry.Create(ctx, &config.AppConfig{Name: "new", APIKey: "static-key"}), apps.ErrAPIKeyUsed)
+		assert.ErrorIs(t, registry.Create(ctx, &config.AppConfig{Name: "new", APIKey: "team-key"}), apps.ErrAPIKeyUsed)
+		assert.ErrorIs(t, registry.Create(ctx, &config.AppConfig{Name: "new", APIKey: "admin-key"}), apps.ErrAPIKeyUsed)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		var names []string
+		for _, app := range registry.List() {
+			names = append(names, app.Name)
+		}
+		assert.Equal(t, []string{"static", "team"}, names)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		err := registry.Update(ctx, &config.AppConfig{Name: "team", Enabled: false, APIKey: "team-key", MaxDurationSec: 60})
+		assert.NoError(t, err)
+
+		app := registry.ByName("team")
+		assert.False(t, app.Enabled)
+		assert.Equal(t, 60, app.MaxDurationSec)
+
+		assert.ErrorIs(t, registry.Update(ctx, &config.AppConfig{Name: "static"}), apps.ErrFromConfig)
+		assert.ErrorIs(t, registry.Update(ctx, &config.AppConfig{Name: "nope"}), apps.ErrNotFound)
+	})
+
+	t.Run("Multiple keys", func(t *testing.T) {
+		expired := time.Now().Add(-time.Minute)
+		err := registry.Update(ctx, &config.AppConfig{Name: "team", Enabled: true, APIKeys: []config.APIKeyConfig{
+			{Label: "old", Key: "old-key", NotAfter: &expired},
+			{Label: "new", Key: "new-key"},
+		}})
+		assert.NoError(t, err)
+
+		app, key := registry.ByAPIKey("new-key")
+		assert.Equal(t, "team", app.Name)
+		assert.Equal(t, "new", key.Label)
+		assert.Empty(t, key.Key)
+
+		app, key = registry.ByAPIKey("old-key")
+		assert.Nil(t, app)
+		assert.Nil(t, key)
+
+		assert.Nil(t, appByKey(registry, "team-key"))
+		assert.ErrorIs(t, registry.Create(ctx, &config.AppConfig{Name: "new", APIKey: "old-key"}), apps.ErrAPIKeyUsed)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.ErrorIs(t, registry.Delete(ctx, "static"), apps.ErrFromConfig)
+		assert.NoError(t, registry.Delete(ctx, "team"))
+		assert.ErrorIs(t, registry.Delete(ctx, "team"), apps.ErrNotFound)
+		assert.Nil(t, appByKey(registry, "new-key"))
+	})
+}
+
+func TestRegistryAPIKeyOwners(t *testing.T) {
+	s := miniredis.RunT(t)
+	cfg := &config.Config{}
+	ctx := context.Background()
+
+	// replicas that didn't see each other changes yet
+	replicas := []*apps.Registry{
+		apps.NewRegistry(cfg, mockValkey(t, s)),
+		apps.NewRegistry(cfg, mockValkey(t, s)),
+	}
+
+	t.Run("Same key on two replicas", func(t *testing.T) {
+		errs := make(chan error, len(replicas))
+		for i, registry := range replicas {
+			go func() {
+				errs <- registry.Create(ctx, &config.AppConfig{Name: fmt.Sprintf("team-%d", i), APIKey: "shared-key"})
+			}()
+		}
+
+		var created, used int
+		for range replicas {
+			switch err := <-errs; {
+			case err == nil:
+				created++
+			case errors.Is(err, apps.ErrAPIKeyUsed):
+				used++
+			default:
+				assert.NoError(t, err)
+			}
+		}
+		assert.Equal(t, 1, created)
+		assert.Equal(t, 1, used)
+	})
+
+	owner := "team-0"
+	if replicas[0].ByName(owner) == nil {
+		owner = "team-1"
+	}
+
+	t.Run("Key released by update", func(t *testing.T) {
+		assert.NoError(t, replicas[0].Update(ctx, &config.AppConfig{Name: owner, APIKey: "rotated-key"}))
+		assert.NoError(t, replicas[1].Create(ctx, &config.AppConfig{Name: "other", APIKey: "shared-key"}))
+		assert.ErrorIs(t, replicas[1].Create(ctx, &config.AppConfig{Name: "late", APIKey: "rotated-key"}), apps.ErrAPIKeyUsed)
+	})
+
+	t.Run("Key released by delete", func(t *testing.T) {
+		assert.NoError(t, replicas[0].Delete(ctx, owner))
+		assert.NoError(t, replicas[1].Create(ctx, &config.AppConfig{Name: "late", APIKey: "rotated-key"}))
+	})
+}
+
+func TestRegistryWatch(t *testing.T) {
+	s := miniredis.RunT(t)
+	cfg := &config.Config{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replica := apps.NewRegistry(cfg, mockValkey(t, s))
+	go replica.Watch(ctx)
+
+	// wait for the subscription
+	assert.Eventually(t, func() bool {
+		return len(s.PubSubChannels("")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	registry := apps.NewRegistry(cfg, mockValkey(t, s))
+	assert.NoError(t, registry.Create(ctx, &config.AppConfig{Name: "team", Enabled: true, APIKey: "team-key"}))
+
+	assert.Eventually(t, func() bool {
+		return appByKey(replica, "team-key") != nil
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, registry.Delete(ctx, "team"))
+
+	assert.Eventually(t, func() bool {
+		return appByKey(replica, "team-key") == nil
+	}, time.Second, 10*time.Millisecond)
+}
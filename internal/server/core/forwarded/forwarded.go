@@ -0,0 +1,222 @@
+package forwarded
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerForwarded = "Forwarded"
+)
+
+var (
+	// headers that can only be trusted when set by a trusted proxy
+	proxyHeaders = []string{
+		headerForwarded,
+		echo.HeaderXForwardedFor,
+		echo.HeaderXForwardedProto,
+		echo.HeaderXForwardedProtocol,
+		echo.HeaderXForwardedSsl,
+		echo.HeaderXUrlScheme,
+		echo.HeaderXRealIP,
+		"X-Forwarded-Host",
+	}
+)
+
+// ParseTrustedProxies parses a list of CIDRs, plain IPs are accepted as a
+// single address range.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// Middleware rewrites the request Host and scheme based on the Forwarded
+// (RFC 7239) and X-Forwarded-* headers, when the request comes from a trusted
+// proxy. Otherwise, those headers are dropped, so echo's Context.Scheme() and
+// the handlers can't be fooled by them.
+func Middleware(trusted []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+
+			if isTrusted(trusted, remoteIP(req)) {
+				applyForwarded(req, trusted)
+			} else {
+				for _, header := range proxyHeaders {
+					req.Header.Del(header)
+				}
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// IPExtractor extracts the client IP from the Forwarded or X-Forwarded-For
+// headers, only trusting the given proxies.
+func IPExtractor(trusted []*net.IPNet) echo.IPExtractor {
+	if len(trusted) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, network := range trusted {
+		options = append(options, echo.TrustIPRange(network))
+	}
+
+	fromXFF := echo.ExtractIPFromXFFHeader(options...)
+
+	return func(req *http.Request) string {
+		if !isTrusted(trusted, remoteIP(req)) {
+			return fromXFF(req)
+		}
+
+		elements := parseForwarded(req.Header.Values(headerForwarded))
+		if len(elements) == 0 {
+			return fromXFF(req)
+		}
+
+		if ip := nodeIP(clientElement(elements, trusted)["for"]); ip != nil {
+			return ip.String()
+		}
+
+		return fromXFF(req)
+	}
+}
+
+func applyForwarded(req *http.Request, trusted []*net.IPNet) {
+	var host, proto string
+
+	if elements := parseForwarded(req.Header.Values(headerForwarded)); len(elements) > 0 {
+		element := clientElement(elements, trusted)
+		host, proto = element["host"], element["proto"]
+	}
+
+	// proxies usually overwrite these, so the last value is the one set by
+	// the closest proxy
+	if host == "" {
+		host = lastValue(req.Header.Get("X-Forwarded-Host"))
+	}
+	if proto == "" {
+		proto = lastValue(req.Header.Get(echo.HeaderXForwardedProto))
+	}
+
+	if host != "" {
+		req.Host = host
+	}
+
+	req.Header.Del(echo.HeaderXForwardedProtocol)
+	req.Header.Del(echo.HeaderXForwardedSsl)
+	req.Header.Del(echo.HeaderXUrlScheme)
+
+	proto = strings.ToLower(proto)
+	if proto == "http" || proto == "https" {
+		req.Header.Set(echo.HeaderXForwardedProto, proto)
+	} else {
+		req.Header.Del(echo.HeaderXForwardedProto)
+	}
+}
+
+// clientElement walks the elements from the closest proxy to the farthest,
+// returning the one added by the first proxy that is reached by the client.
+func clientElement(elements []map[string]string, trusted []*net.IPNet) map[string]string {
+	for i := len(elements) - 1; i > 0; i-- {
+		ip := nodeIP(elements[i]["for"])
+		if ip == nil || !isTrusted(trusted, ip) {
+			return elements[i]
+		}
+	}
+	return elements[0]
+}
+
+func parseForwarded(values []string) []map[string]string {
+	var elements []map[string]string
+
+	for _, value := range values {
+		for _, rawElement := range strings.Split(value, ",") {
+			element := make(map[string]string)
+
+			for _, pair := range strings.Split(rawElement, ";") {
+				key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found {
+					continue
+				}
+				element[strings.ToLower(key)] = strings.Trim(value, `"`)
+			}
+
+			if len(element) > 0 {
+				elements = append(elements, element)
+			}
+		}
+	}
+
+	return elements
+}
+
+// nodeIP parses RFC 7239 nodes, such as 192.0.2.43, "192.0.2.43:47011" or
+// "[2001:db8:cafe::17]:4711". Obfuscated and unknown nodes are nil.
+func nodeIP(node string) net.IP {
+	if strings.HasPrefix(node, "[") {
+		if end := strings.Index(node, "]"); end != -1 {
+			return net.ParseIP(node[1:end])
+		}
+		return nil
+	}
+
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+
+	return net.ParseIP(node)
+}
+
+func remoteIP(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func isTrusted(trusted []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lastValue(header string) string {
+	values := strings.Split(header, ",")
+	return strings.TrimSpace(values[len(values)-1])
+}
@@ -0,0 +1,99 @@
+package forwarded_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/server/core/forwarded"
+	"github.com/stretchr/testify/assert"
+)
+
+type requestInfo struct {
+	Host   string
+	Scheme string
+	IP     string
+}
+
+func doRequest(t *testing.T, proxies []string, remoteAddr string, headers map[string]string) requestInfo {
+	trusted, err := forwarded.ParseTrustedProxies(proxies)
+	assert.NoError(t, err)
+
+	e := echo.New()
+	e.IPExtractor = forwarded.IPExtractor(trusted)
+	e.Pre(forwarded.Middleware(trusted))
+
+	var info requestInfo
+	e.GET("/", func(ctx echo.Context) error {
+		info = requestInfo{ctx.Request().Host, ctx.Scheme(), ctx.RealIP()}
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "shurl.internal.svc"
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	return info
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := forwarded.ParseTrustedProxies([]string{"10.0.0.0/8", "127.0.0.1", "::1"})
+	assert.NoError(t, err)
+	assert.Len(t, networks, 3)
+	assert.Equal(t, "127.0.0.1/32", networks[1].String())
+	assert.Equal(t, "::1/128", networks[2].String())
+
+	_, err = forwarded.ParseTrustedProxies([]string{"not an ip"})
+	assert.Error(t, err)
+}
+
+func TestUntrustedProxy(t *testing.T) {
+	info := doRequest(t, []string{"10.0.0.0/8"}, "203.0.113.7:1234", map[string]string{
+		"X-Forwarded-Host":  "evil.example.com",
+		"X-Forwarded-Proto": "https",
+		"X-Forwarded-For":   "1.2.3.4",
+		"Forwarded":         "for=1.2.3.4;host=evil.example.com;proto=https",
+	})
+
+	assert.Equal(t, requestInfo{"shurl.internal.svc", "http", "203.0.113.7"}, info)
+}
+
+func TestNoTrustedProxies(t *testing.T) {
+	info := doRequest(t, nil, "127.0.0.1:1234", map[string]string{
+		"X-Forwarded-Host": "evil.example.com",
+		"X-Forwarded-For":  "1.2.3.4",
+	})
+
+	assert.Equal(t, requestInfo{"shurl.internal.svc", "http", "127.0.0.1"}, info)
+}
+
+func TestTrustedXForwarded(t *testing.T) {
+	info := doRequest(t, []string{"10.0.0.0/8"}, "10.0.0.2:1234", map[string]string{
+		"X-Forwarded-Host":  "sh.example.com",
+		"X-Forwarded-Proto": "https",
+		"X-Forwarded-For":   "6.6.6.6, 198.51.100.1, 10.0.0.3",
+	})
+
+	assert.Equal(t, requestInfo{"sh.example.com", "https", "198.51.100.1"}, info)
+}
+
+func TestTrustedForwarded(t *testing.T) {
+	info := doRequest(t, []string{"10.0.0.0/8"}, "10.0.0.2:1234", map[string]string{
+		"Forwarded": `for=6.6.6.6;host=evil.example.com, for="[2001:db8:cafe::17]:4711";host=sh.example.com;proto=https, for=10.0.0.3;host=shurl.internal.svc;proto=http`,
+	})
+
+	assert.Equal(t, requestInfo{"sh.example.com", "https", "2001:db8:cafe::17"}, info)
+}
+
+func TestTrustedForwardedInvalidProto(t *testing.T) {
+	info := doRequest(t, []string{"10.0.0.0/8"}, "10.0.0.2:1234", map[string]string{
+		"Forwarded": "for=198.51.100.1;proto=javascript",
+	})
+
+	assert.Equal(t, requestInfo{"shurl.internal.svc", "http", "198.51.100.1"}, info)
+}
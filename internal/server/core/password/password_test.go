@@ -0,0 +1,40 @@
+package password_test
+
+import (
+	"testing"
+
+	"github.com/pauloo27/shurl/internal/server/core/password"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := password.Hash("hunter2")
+	assert.NoError(t, err)
+	assert.Contains(t, hash, "$argon2id$")
+
+	ok, err := password.Verify("hunter2", hash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = password.Verify("hunter3", hash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestHashIsSalted(t *testing.T) {
+	first, err := password.Hash("hunter2")
+	assert.NoError(t, err)
+
+	second, err := password.Hash("hunter2")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	for _, hash := range []string{"", "plain", "$bcrypt$v=19$m=1,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=x$c2FsdA$a2V5"} {
+		ok, err := password.Verify("hunter2", hash)
+		assert.ErrorIs(t, err, password.ErrInvalidHash, hash)
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/urlnorm"
+)
+
+var (
+	ErrBlocked    = errors.New("destination domain is blocked")
+	ErrNotAllowed = errors.New("destination domain is not allowed")
+)
+
+type Engine struct {
+	global *Policy
+
+	mu   sync.Mutex
+	apps map[*config.PolicyConfig]*Policy
+}
+
+func NewEngine(global *config.PolicyConfig) *Engine {
+	return &Engine{
+		global: New(global),
+		apps:   make(map[*config.PolicyConfig]*Policy),
+	}
+}
+
+// Check validates the destination rawURL against both the global and the
+// app policies.
+func (e *Engine) Check(app *config.AppConfig, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	// the domain rules only make sense for web destinations, deep links and
+	// mailto: have no real host
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return nil
+	}
+
+	host := u.Hostname()
+
+	if err := e.global.Check(host); err != nil {
+		return err
+	}
+
+	return e.appPolicy(app).Check(host)
+}
+
+func (e *Engine) appPolicy(app *config.AppConfig) *Policy {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	p, found := e.apps[app.Policy]
+	if !found {
+		p = New(app.Policy)
+		e.apps[app.Policy] = p
+	}
+
+	return p
+}
+
+type Policy struct {
+	allow *rules
+	block *rules
+
+	filePath    string
+	mu          sync.RWMutex
+	fileModTime time.Time
+	// last error when stating the file, so it's logged only when it changes
+	fileStatErr string
+	fileAllow   *rules
+	fileBlock   *rules
+}
+
+func New(cfg *config.PolicyConfig) *Policy {
+	if cfg == nil {
+		return &Policy{}
+	}
+
+	return &Policy{
+		allow:    compileRules(cfg.Allow),
+		block:    compileRules(cfg.Block),
+		filePath: cfg.File,
+	}
+}
+
+// Check validates the destination host. It's mapped to its ASCII form first,
+// whether the app normalizes URLs or not, so other spellings of a blocked
+// domain are blocked too.
+func (p *Policy) Check(host string) error {
+	host = urlnorm.Hostname(host)
+
+	p.reloadFileIfChanged()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.block.match(host) || p.fileBlock.match(host) {
+		return ErrBlocked
+	}
+
+	if p.allow.empty() && p.fileAllow.empty() {
+		return nil
+	}
+
+	if p.allow.match(host) || p.fileAllow.match(host) {
+		return nil
+	}
+
+	return ErrNotAllowed
+}
+
+func (p *Policy) reloadFileIfChanged() {
+	if p.filePath == "" {
+		return
+	}
+
+	info, err := os.Stat(p.filePath)
+	if err != nil {
+		p.setFileStatErr(err)
+		return
+	}
+
+	p.mu.RLock()
+	unchanged := info.ModTime().Equal(p.fileModTime) && p.fileStatErr == ""
+	p.mu.RUnlock()
+
+	if unchanged {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fileStatErr = ""
+	// broken files are not retried until they change again, the previous
+	// rules are kept meanwhile
+	p.fileModTime = info.ModTime()
+
+	/* #nosec G304 */
+	data, err := os.ReadFile(p.filePath)
+	if err != nil {
+		slog.Error("Failed to read policy file", "path", p.filePath, "err", err)
+		return
+	}
+
+	var fileCfg config.PolicyConfig
+	if err := yaml.Unmarshal(data, &fileCfg); err != nil {
+		slog.Error("Failed to parse policy file", "path", p.filePath, "err", err)
+		return
+	}
+
+	p.fileAllow = compileRules(fileCfg.Allow)
+	p.fileBlock = compileRules(fileCfg.Block)
+
+	slog.Info("Policy file loaded", "path", p.filePath)
+}
+
+func (p *Policy) setFileStatErr(err error) {
+	p.mu.RLock()
+	same := p.fileStatErr == err.Error()
+	p.mu.RUnlock()
+
+	if same {
+		return
+	}
+
+	p.mu.Lock()
+	p.fileStatErr = err.Error()
+	p.mu.Unlock()
+
+	slog.Error("Failed to stat policy file", "path", p.filePath, "err", err)
+}
+
+type rules struct {
+	exact  map[string]bool
+	suffix []string
+	regex  []*regexp.Regexp
+}
+
+func compileRules(cfg config.DomainRules) *rules {
+	r := &rules{
+		exact: make(map[string]bool),
+	}
+
+	for _, domain := range cfg.Exact {
+		r.exact[urlnorm.Hostname(domain)] = true
+	}
+
+	for _, suffix := range cfg.Suffix {
+		suffix = strings.TrimPrefix(strings.TrimPrefix(suffix, "*"), ".")
+		r.suffix = append(r.suffix, urlnorm.Hostname(suffix))
+	}
+
+	for _, pattern := range cfg.Regex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			slog.Error("Invalid policy regex, ignoring it", "pattern", pattern, "err", err)
+			continue
+		}
+		r.regex = append(r.regex, re)
+	}
+
+	return r
+}
+
+func (r *rules) empty() bool {
+	return r == nil || (len(r.exact) == 0 && len(r.suffix) == 0 && len(r.regex) == 0)
+}
+
+func (r *rules) match(host string) bool {
+	if r == nil {
+		return false
+	}
+
+	if r.exact[host] {
+		return true
+	}
+
+	for _, suffix := range r.suffix {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+
+	for _, re := range r.regex {
+		if re.MatchString(host) {
+			return true
+		}
+	}
+
+	return false
+}
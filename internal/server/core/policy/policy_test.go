@@ -0,0 +1,151 @@
+package policy_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmptyPolicy(t *testing.T) {
+	p := policy.New(nil)
+	assert.NoError(t, p.Check("example.com"))
+}
+
+func TestBlockRules(t *testing.T) {
+	p := policy.New(&config.PolicyConfig{
+		Block: config.DomainRules{
+			Exact:  []string{"evil.com"},
+			Suffix: []string{"*.phishing.org"},
+			Regex:  []string{`^malware-\d+\.net$`},
+		},
+	})
+
+	assert.ErrorIs(t, p.Check("evil.com"), policy.ErrBlocked)
+	assert.ErrorIs(t, p.Check("EVIL.com."), policy.ErrBlocked)
+	assert.NoError(t, p.Check("not.evil.com"))
+
+	assert.ErrorIs(t, p.Check("phishing.org"), policy.ErrBlocked)
+	assert.ErrorIs(t, p.Check("login.phishing.org"), policy.ErrBlocked)
+	assert.NoError(t, p.Check("notphishing.org"))
+
+	assert.ErrorIs(t, p.Check("malware-1337.net"), policy.ErrBlocked)
+	assert.NoError(t, p.Check("malware-x.net"))
+}
+
+func TestBlockRulesOtherSpellings(t *testing.T) {
+	p := policy.New(&config.PolicyConfig{
+		Block: config.DomainRules{
+			Exact:  []string{"grabify.link"},
+			Suffix: []string{"bücher.example"},
+		},
+	})
+
+	assert.ErrorIs(t, p.Check("ｇｒａｂｉｆｙ.link"), policy.ErrBlocked)
+	assert.ErrorIs(t, p.Check("ｇｒａｂｉｆｙ．ｌｉｎｋ"), policy.ErrBlocked)
+	assert.ErrorIs(t, p.Check("xn--bcher-kva.example"), policy.ErrBlocked)
+	assert.ErrorIs(t, p.Check("shop.BÜCHER.example"), policy.ErrBlocked)
+	assert.NoError(t, p.Check("grabify.com"))
+
+	e := policy.NewEngine(&config.PolicyConfig{Block: config.DomainRules{Exact: []string{"grabify.link"}}})
+	assert.ErrorIs(t, e.Check(&config.AppConfig{}, "http://ｇｒａｂｉｆｙ.link/"), policy.ErrBlocked)
+}
+
+func TestAllowRules(t *testing.T) {
+	p := policy.New(&config.PolicyConfig{
+		Allow: config.DomainRules{
+			Suffix: []string{"example.com"},
+		},
+		Block: config.DomainRules{
+			Exact: []string{"bad.example.com"},
+		},
+	})
+
+	assert.NoError(t, p.Check("example.com"))
+	assert.NoError(t, p.Check("docs.example.com"))
+	assert.ErrorIs(t, p.Check("bad.example.com"), policy.ErrBlocked)
+	assert.ErrorIs(t, p.Check("example.org"), policy.ErrNotAllowed)
+}
+
+func TestEngine(t *testing.T) {
+	e := policy.NewEngine(&config.PolicyConfig{
+		Block: config.DomainRules{Exact: []string{"evil.com"}},
+	})
+
+	app := &config.AppConfig{
+		Policy: &config.PolicyConfig{
+			Block: config.DomainRules{Exact: []string{"competitor.com"}},
+		},
+	}
+
+	assert.ErrorIs(t, e.Check(app, "http://evil.com/x"), policy.ErrBlocked)
+	assert.ErrorIs(t, e.Check(app, "https://competitor.com"), policy.ErrBlocked)
+	assert.NoError(t, e.Check(app, "https://example.com"))
+
+	assert.ErrorIs(t, e.Check(&config.AppConfig{}, "http://evil.com/x"), policy.ErrBlocked)
+	assert.NoError(t, e.Check(&config.AppConfig{}, "https://competitor.com"))
+}
+
+func TestEngineSkipsNonWebDestinations(t *testing.T) {
+	e := policy.NewEngine(&config.PolicyConfig{
+		Allow: config.DomainRules{Suffix: []string{"example.com"}},
+	})
+
+	app := &config.AppConfig{}
+	assert.NoError(t, e.Check(app, "myapp://open/item/1"))
+	assert.NoError(t, e.Check(app, "mailto:someone@example.org"))
+	assert.NoError(t, e.Check(app, "tel:+5511999999999"))
+	assert.ErrorIs(t, e.Check(app, "https://example.org"), policy.ErrNotAllowed)
+}
+
+func TestPolicyFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeFile := func(data string, modTime time.Time) {
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	now := time.Now()
+	writeFile("block: { exact: ['evil.com'] }", now.Add(-time.Minute))
+
+	p := policy.New(&config.PolicyConfig{File: path})
+	assert.ErrorIs(t, p.Check("evil.com"), policy.ErrBlocked)
+	assert.NoError(t, p.Check("example.com"))
+
+	writeFile("block: { exact: ['example.com'] }", now)
+	assert.NoError(t, p.Check("evil.com"))
+	assert.ErrorIs(t, p.Check("example.com"), policy.ErrBlocked)
+
+	// broken files keep the previous rules
+	writeFile("block: [", now.Add(time.Minute))
+	assert.ErrorIs(t, p.Check("example.com"), policy.ErrBlocked)
+}
+
+func TestMissingPolicyFileLoggedOnce(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	p := policy.New(&config.PolicyConfig{File: path})
+
+	for range 3 {
+		assert.NoError(t, p.Check("evil.com"))
+	}
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("Failed to stat policy file")))
+
+	// back once it shows up
+	assert.NoError(t, os.WriteFile(path, []byte("block: { exact: ['evil.com'] }"), 0o600))
+	assert.ErrorIs(t, p.Check("evil.com"), policy.ErrBlocked)
+
+	assert.NoError(t, os.Remove(path))
+	assert.ErrorIs(t, p.Check("evil.com"), policy.ErrBlocked)
+	assert.Equal(t, 2, bytes.Count(logs.Bytes(), []byte("Failed to stat policy file")))
+}
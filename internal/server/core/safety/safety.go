@@ -0,0 +1,264 @@
+package safety
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/urlnorm"
+)
+
+const (
+	defaultMaxChainDepth = 3
+	resolveTimeout       = 3 * time.Second
+)
+
+type UnsafeError struct {
+	Reason string
+}
+
+func (e *UnsafeError) Error() string {
+	return e.Reason
+}
+
+var (
+	ErrPrivateNetwork = &UnsafeError{"Destination resolves to a private network"}
+	ErrUnresolvable   = &UnsafeError{"Destination host could not be resolved"}
+	ErrSelfReference  = &UnsafeError{"Destination can't be another short link"}
+	ErrChainTooDeep   = &UnsafeError{"Destination short link chain is too deep"}
+	ErrChainLoop      = &UnsafeError{"Destination short link chain has a loop"}
+)
+
+var (
+	// ranges not covered by the net.IP helpers
+	reservedNetworks = mustParseCIDRs(
+		"0.0.0.0/8",
+		"100.64.0.0/10",
+		"192.0.0.0/24",
+		"198.18.0.0/15",
+	)
+)
+
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// LinkLookup finds every URL the link with the given domain and slug may
+// redirect to (the original URL, its variants, rules and fallback), so chains
+// of short links can be followed.
+type LinkLookup func(ctx context.Context, domain, slug string) (urls []string, found bool, err error)
+
+// Namespace maps a host of a short URL, such as an alias or a host with a
+// port, to the domain its links are stored under.
+type Namespace func(host string) string
+
+type Checker struct {
+	cfg           *config.SafetyConfig
+	servedDomains []string
+	resolver      Resolver
+	lookup        LinkLookup
+	namespace     Namespace
+}
+
+func NewChecker(cfg *config.SafetyConfig, servedDomains []string, lookup LinkLookup, namespace Namespace) *Checker {
+	if namespace == nil {
+		namespace = hostname
+	}
+	return &Checker{cfg, servedDomains, net.DefaultResolver, lookup, namespace}
+}
+
+// WithResolver replaces the DNS resolver used to check the destination hosts.
+func (c *Checker) WithResolver(resolver Resolver) *Checker {
+	c.resolver = resolver
+	return c
+}
+
+// Check validates that rawURL, to be stored as slug of the short link host,
+// doesn't point to a private network nor to a short link of this server (the
+// served domains and the host itself), unless chaining is allowed. The
+// returned error is an *UnsafeError when the destination is rejected.
+func (c *Checker) Check(ctx context.Context, host, slug, rawURL string) error {
+	if c.cfg == nil {
+		return nil
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	// deep links, mailto: and the like don't point to a network host
+	if !isWeb(target) {
+		return nil
+	}
+
+	path := map[string]bool{c.namespace(host) + "/" + slug: true}
+	ends, err := c.followChain(ctx, host, target, 1, path)
+	if err != nil {
+		return err
+	}
+
+	if !c.cfg.BlockPrivateNetworks {
+		return nil
+	}
+
+	for _, end := range ends {
+		if end.Hostname() == "" {
+			continue
+		}
+		if err := c.checkHost(ctx, urlnorm.Hostname(end.Hostname())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// followChain follows target through the short links of this server,
+// returning the destinations outside of it the chain may end in. Every URL of
+// a link is followed, not only the original one, and path holds the links
+// of the current chain, to find loops. Chains ending in a missing short link
+// stay within this server, so they end nowhere.
+func (c *Checker) followChain(
+	ctx context.Context, requestHost string, target *url.URL, depth int, path map[string]bool,
+) ([]*url.URL, error) {
+	if !isWeb(target) || !c.isServed(target.Hostname(), requestHost) {
+		return []*url.URL{target}, nil
+	}
+
+	if !c.cfg.AllowChaining {
+		return nil, ErrSelfReference
+	}
+
+	maxDepth := c.cfg.MaxChainDepth
+	if maxDepth == 0 {
+		maxDepth = defaultMaxChainDepth
+	}
+
+	if depth > maxDepth {
+		return nil, ErrChainTooDeep
+	}
+
+	host := urlnorm.Hostname(target.Hostname())
+	if port := target.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+
+	targetDomain := c.namespace(host)
+	targetSlug := strings.TrimPrefix(target.Path, "/")
+
+	link := targetDomain + "/" + targetSlug
+	if path[link] {
+		return nil, ErrChainLoop
+	}
+
+	urls, found, err := c.lookup(ctx, targetDomain, targetSlug)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	path[link] = true
+	defer delete(path, link)
+
+	var ends []*url.URL
+	for _, rawURL := range urls {
+		next, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		nextEnds, err := c.followChain(ctx, requestHost, next, depth+1, path)
+		if err != nil {
+			return nil, err
+		}
+		ends = append(ends, nextEnds...)
+	}
+
+	return ends, nil
+}
+
+// isServed tells if host is one of the domains of this server, other
+// spellings of them (such as fullwidth letters) included.
+func (c *Checker) isServed(host, requestHost string) bool {
+	host = urlnorm.Hostname(host)
+
+	if host == hostname(requestHost) {
+		return true
+	}
+
+	for _, served := range c.servedDomains {
+		if host == hostname(served) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Checker) checkHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if IsPrivateIP(ip) {
+			return ErrPrivateNetwork
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	addrs, err := c.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrUnresolvable
+	}
+
+	for _, addr := range addrs {
+		if IsPrivateIP(addr.IP) {
+			return ErrPrivateNetwork
+		}
+	}
+
+	return nil
+}
+
+func IsPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWeb(u *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+func hostname(domain string) string {
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+	return urlnorm.Hostname(domain)
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
@@ -0,0 +1,195 @@
+package safety_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/safety"
+	"github.com/stretchr/testify/assert"
+)
+
+func lookupFrom(links map[string]string) safety.LinkLookup {
+	all := make(map[string][]string, len(links))
+	for link, url := range links {
+		all[link] = []string{url}
+	}
+	return lookupAllFrom(all)
+}
+
+// lookupAllFrom finds links with many URLs, such as variants and rules.
+func lookupAllFrom(links map[string][]string) safety.LinkLookup {
+	return func(_ context.Context, domain, slug string) ([]string, bool, error) {
+		urls, found := links[domain+"/"+slug]
+		return urls, found, nil
+	}
+}
+
+// fakeResolver resolves the hosts in the map, any other host fails.
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, found := r[host]
+	if !found {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+	return addrs, nil
+}
+
+func TestIsPrivateIP(t *testing.T) {
+	private := []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"0.0.0.0", "100.64.0.1", "::1", "fe80::1", "fc00::1", "::ffff:127.0.0.1",
+	}
+	public := []string{"1.1.1.1", "8.8.8.8", "2606:4700:4700::1111"}
+
+	for _, ip := range private {
+		assert.True(t, safety.IsPrivateIP(net.ParseIP(ip)), ip)
+	}
+
+	for _, ip := range public {
+		assert.False(t, safety.IsPrivateIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestDisabledChecker(t *testing.T) {
+	c := safety.NewChecker(nil, nil, lookupFrom(nil), nil)
+	assert.NoError(t, c.Check(context.Background(), "sh.test", "slug", "http://127.0.0.1"))
+}
+
+func TestPrivateNetworks(t *testing.T) {
+	c := safety.NewChecker(&config.SafetyConfig{BlockPrivateNetworks: true}, nil, lookupFrom(nil), nil).
+		WithResolver(fakeResolver{
+			"localhost":   {"127.0.0.1", "::1"},
+			"example.com": {"93.184.215.14"},
+			"rebind.test": {"93.184.215.14", "10.0.0.1"},
+		})
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "http://127.0.0.1:8080/admin"), safety.ErrPrivateNetwork)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "http://169.254.169.254/"), safety.ErrPrivateNetwork)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "http://[::1]/"), safety.ErrPrivateNetwork)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "http://localhost/"), safety.ErrPrivateNetwork)
+	assert.NoError(t, c.Check(ctx, "sh.test", "slug", "http://1.1.1.1/"))
+	assert.NoError(t, c.Check(ctx, "sh.test", "slug", "http://example.com/"))
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "http://rebind.test/"), safety.ErrPrivateNetwork)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "http://nope.test/"), safety.ErrUnresolvable)
+
+	// no network host to resolve
+	assert.NoError(t, c.Check(ctx, "sh.test", "slug", "myapp://open/item/1"))
+	assert.NoError(t, c.Check(ctx, "sh.test", "slug", "mailto:someone@example.com"))
+	assert.NoError(t, c.Check(ctx, "sh.test", "slug", "tel:+5511999999999"))
+}
+
+func TestSelfReference(t *testing.T) {
+	c := safety.NewChecker(&config.SafetyConfig{}, []string{"s.test"}, lookupFrom(nil), nil)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Check(ctx, "sh.test:8080", "slug", "http://sh.test/other"), safety.ErrSelfReference)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "https://S.TEST/other"), safety.ErrSelfReference)
+	assert.NoError(t, c.Check(ctx, "sh.test", "slug", "https://example.com/"))
+}
+
+func TestChaining(t *testing.T) {
+	links := map[string]string{
+		"sh.test/a":    "http://sh.test/b",
+		"sh.test/b":    "http://sh.test/c",
+		"sh.test/c":    "http://127.0.0.1",
+		"sh.test/loop": "http://sh.test/new",
+	}
+
+	c := safety.NewChecker(&config.SafetyConfig{
+		BlockPrivateNetworks: true,
+		AllowChaining:        true,
+		MaxChainDepth:        2,
+	}, nil, lookupFrom(links), nil)
+	ctx := context.Background()
+
+	assert.NoError(t, c.Check(ctx, "sh.test", "new", "http://sh.test/missing"))
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://sh.test/b"), safety.ErrPrivateNetwork)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://sh.test/a"), safety.ErrChainTooDeep)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://sh.test/loop"), safety.ErrChainLoop)
+}
+
+func TestChainingThroughAliases(t *testing.T) {
+	links := map[string]string{
+		"sh.test/a":    "http://s.test/b",
+		"sh.test/b":    "http://SH.test:8080/c",
+		"sh.test/c":    "http://127.0.0.1",
+		"sh.test/loop": "http://s.test:8080/new",
+	}
+
+	// s.test is an alias of sh.test
+	namespace := func(host string) string {
+		host = strings.ToLower(host)
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		if host == "s.test" {
+			return "sh.test"
+		}
+		return host
+	}
+
+	c := safety.NewChecker(&config.SafetyConfig{
+		BlockPrivateNetworks: true,
+		AllowChaining:        true,
+		MaxChainDepth:        2,
+	}, []string{"sh.test", "s.test"}, lookupFrom(links), namespace)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://s.test/b"), safety.ErrPrivateNetwork)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://s.test:8080/a"), safety.ErrChainTooDeep)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://s.test/loop"), safety.ErrChainLoop)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://s.test/new"), safety.ErrChainLoop)
+}
+
+func TestChainingThroughOtherSpellings(t *testing.T) {
+	c := safety.NewChecker(&config.SafetyConfig{}, []string{"sh.test"}, lookupFrom(nil), nil)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "slug", "http://ｓｈ.ｔｅｓｔ/other"), safety.ErrSelfReference)
+	assert.ErrorIs(t, c.Check(ctx, "bücher.test", "slug", "http://xn--bcher-kva.test/other"), safety.ErrSelfReference)
+	assert.ErrorIs(t, c.Check(ctx, "xn--bcher-kva.test", "slug", "http://BÜCHER.test./other"), safety.ErrSelfReference)
+}
+
+func TestChainingThroughEveryURL(t *testing.T) {
+	links := map[string][]string{
+		"sh.test/split":   {"http://example.com/a", "http://sh.test/inner"},
+		"sh.test/inner":   {"http://example.com/b", "http://10.0.0.1/"},
+		"sh.test/rules":   {"http://example.com/", "http://sh.test/new"},
+		"sh.test/diamond": {"http://sh.test/safe", "http://sh.test/safe"},
+		"sh.test/safe":    {"http://192.0.2.10/"},
+	}
+
+	c := safety.NewChecker(&config.SafetyConfig{
+		BlockPrivateNetworks: true,
+		AllowChaining:        true,
+	}, nil, lookupAllFrom(links), nil).WithResolver(fakeResolver{"example.com": {"93.184.216.34"}})
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://sh.test/split"), safety.ErrPrivateNetwork)
+	assert.ErrorIs(t, c.Check(ctx, "sh.test", "new", "http://sh.test/rules"), safety.ErrChainLoop)
+	assert.NoError(t, c.Check(ctx, "sh.test", "new", "http://sh.test/diamond"))
+}
+
+func TestLookupError(t *testing.T) {
+	lookupErr := errors.New("boom")
+	c := safety.NewChecker(&config.SafetyConfig{AllowChaining: true}, nil, func(context.Context, string, string) ([]string, bool, error) {
+		return nil, false, lookupErr
+	}, nil)
+
+	err := c.Check(context.Background(), "sh.test", "new", "http://sh.test/a")
+	assert.ErrorIs(t, err, lookupErr)
+
+	var unsafeErr *safety.UnsafeError
+	assert.False(t, errors.As(err, &unsafeErr))
+}
@@ -0,0 +1,167 @@
+package targeting
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pauloo27/shurl/internal/models"
+)
+
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+	PlatformOther   = "other"
+
+	// groups of platforms
+	PlatformMobile  = "mobile"
+	PlatformDesktop = "desktop"
+)
+
+var (
+	platformGroups = map[string][]string{
+		PlatformMobile:  {PlatformIOS, PlatformAndroid},
+		PlatformDesktop: {PlatformWindows, PlatformMacOS, PlatformLinux},
+	}
+)
+
+// IsPlatform tells if name can be used in the platforms of a rule.
+func IsPlatform(name string) bool {
+	switch name {
+	case PlatformIOS, PlatformAndroid, PlatformWindows, PlatformMacOS, PlatformLinux, PlatformOther,
+		PlatformMobile, PlatformDesktop:
+		return true
+	}
+	return false
+}
+
+// Platform guesses the platform of the client from its User-Agent.
+func Platform(userAgent string) string {
+	// the order matters, iOS claims to be "like Mac OS X" and Android is Linux
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "iPod"):
+		return PlatformIOS
+	case strings.Contains(userAgent, "Android"):
+		return PlatformAndroid
+	case strings.Contains(userAgent, "Windows"):
+		return PlatformWindows
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		return PlatformMacOS
+	case strings.Contains(userAgent, "Linux"), strings.Contains(userAgent, "X11"), strings.Contains(userAgent, "CrOS"):
+		return PlatformLinux
+	default:
+		return PlatformOther
+	}
+}
+
+// PreferredLanguage is the lowercased language with the highest quality in
+// an Accept-Language header, empty when there's none.
+func PreferredLanguage(acceptLanguage string) string {
+	preferred := ""
+	preferredQuality := 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if rawQuality, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			q, err := strconv.ParseFloat(rawQuality, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+
+		if quality > preferredQuality {
+			preferred = tag
+			preferredQuality = quality
+		}
+	}
+
+	return preferred
+}
+
+// Pick finds the first rule matching the request.
+func Pick(rules []models.Rule, req *http.Request) (int, bool) {
+	if len(rules) == 0 {
+		return 0, false
+	}
+
+	platform := Platform(req.UserAgent())
+	language := PreferredLanguage(req.Header.Get("Accept-Language"))
+
+	var referrer string
+	if u, err := url.Parse(req.Referer()); err == nil {
+		referrer = strings.ToLower(u.Hostname())
+	}
+
+	for i, rule := range rules {
+		if matchPlatform(rule.Platforms, platform) &&
+			matchLanguage(rule.Languages, language) &&
+			matchReferrer(rule.Referrers, referrer) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func matchPlatform(platforms []string, platform string) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+
+	for _, p := range platforms {
+		if p == platform || slices.Contains(platformGroups[p], platform) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchLanguage matches "pt" with "pt" and "pt-br", but "pt-br" only with
+// "pt-br".
+func matchLanguage(languages []string, language string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+
+	for _, l := range languages {
+		l = strings.ToLower(l)
+		if language == l || strings.HasPrefix(language, l+"-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchReferrer matches the referrer host and its subdomains.
+func matchReferrer(referrers []string, referrer string) bool {
+	if len(referrers) == 0 {
+		return true
+	}
+
+	if referrer == "" {
+		return false
+	}
+
+	for _, r := range referrers {
+		r = strings.ToLower(r)
+		if referrer == r || strings.HasSuffix(referrer, "."+r) {
+			return true
+		}
+	}
+
+	return false
+}
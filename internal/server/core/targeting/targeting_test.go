@@ -0,0 +1,105 @@
+package targeting_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/core/targeting"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"
+	windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+	macUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Version/17.0 Safari/605.1.15"
+	linuxUA   = "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0"
+)
+
+func TestPlatform(t *testing.T) {
+	cases := map[string]string{
+		iPhoneUA:     targeting.PlatformIOS,
+		androidUA:    targeting.PlatformAndroid,
+		windowsUA:    targeting.PlatformWindows,
+		macUA:        targeting.PlatformMacOS,
+		linuxUA:      targeting.PlatformLinux,
+		"curl/8.0.1": targeting.PlatformOther,
+		"":           targeting.PlatformOther,
+	}
+
+	for ua, platform := range cases {
+		assert.Equal(t, platform, targeting.Platform(ua), ua)
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                             "",
+		"*":                            "",
+		"pt-BR":                        "pt-br",
+		"en-US,en;q=0.9,pt;q=0.8":      "en-us",
+		"en;q=0.5, pt-BR;q=0.9, *;q=1": "pt-br",
+		"en;q=0, fr":                   "fr",
+		"en;q=invalid, fr;q=0.1":       "fr",
+	}
+
+	for header, language := range cases {
+		assert.Equal(t, language, targeting.PreferredLanguage(header), header)
+	}
+}
+
+func TestPick(t *testing.T) {
+	rules := []models.Rule{
+		{URL: "ios", Platforms: []string{"ios"}},
+		{URL: "android", Platforms: []string{"android"}},
+		{URL: "mobile-pt", Platforms: []string{"mobile"}, Languages: []string{"pt"}},
+		{URL: "social", Referrers: []string{"facebook.com"}},
+		{URL: "desktop", Platforms: []string{"desktop"}},
+	}
+
+	cases := []struct {
+		name     string
+		ua       string
+		language string
+		referrer string
+		url      string
+	}{
+		{"iOS", iPhoneUA, "", "", "ios"},
+		{"Android", androidUA, "pt-BR", "", "android"},
+		{"Desktop", windowsUA, "", "", "desktop"},
+		{"Referrer subdomain", linuxUA, "", "https://m.facebook.com/story", "social"},
+		{"Referrer lookalike", macUA, "", "https://notfacebook.com/", "desktop"},
+		{"No match", "curl/8.0.1", "en", "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("User-Agent", c.ua)
+			req.Header.Set("Accept-Language", c.language)
+			req.Header.Set("Referer", c.referrer)
+
+			i, found := targeting.Pick(rules, req)
+			if c.url == "" {
+				assert.False(t, found)
+				return
+			}
+			assert.True(t, found)
+			assert.Equal(t, c.url, rules[i].URL)
+		})
+	}
+
+	t.Run("Language", func(t *testing.T) {
+		rules := []models.Rule{{URL: "pt", Languages: []string{"pt"}}, {URL: "pt-br", Languages: []string{"pt-BR"}}}
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", "pt-PT")
+		i, found := targeting.Pick(rules[1:], req)
+		assert.False(t, found)
+
+		i, found = targeting.Pick(rules, req)
+		assert.True(t, found)
+		assert.Equal(t, "pt", rules[i].URL)
+	})
+}
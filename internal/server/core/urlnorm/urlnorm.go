@@ -0,0 +1,226 @@
+package urlnorm
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"golang.org/x/net/idna"
+)
+
+var (
+	defaultPorts = map[string]string{
+		"http":  "80",
+		"https": "443",
+	}
+
+	trackingParams = map[string]bool{
+		"fbclid":  true,
+		"gclid":   true,
+		"dclid":   true,
+		"msclkid": true,
+		"yclid":   true,
+		"igshid":  true,
+		"mc_cid":  true,
+		"mc_eid":  true,
+	}
+
+	trackingParamPrefixes = []string{"utm_"}
+
+	idnaProfile = idna.New(
+		idna.MapForLookup(),
+		idna.BidiRule(),
+		// underscores are not valid in hostnames, but they are out there
+		idna.StrictDomainName(false),
+	)
+)
+
+// Normalize returns the canonical form of rawURL according to cfg. When cfg
+// is nil or disabled, rawURL is returned untouched.
+func Normalize(rawURL string, cfg *config.NormalizeConfig) (string, error) {
+	if cfg == nil || !cfg.Enabled {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	// opaque urls (such as mailto:) have no host or path to normalize and
+	// the meaning of custom schemes (such as app deep links) is unknown
+	if _, isHTTP := defaultPorts[u.Scheme]; !isHTTP || u.Opaque != "" {
+		return u.String(), nil
+	}
+
+	if err := normalizeHost(u); err != nil {
+		return "", err
+	}
+
+	if err := normalizePath(u); err != nil {
+		return "", err
+	}
+
+	u.RawQuery = normalizeQuery(u.RawQuery, cfg)
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
+
+	return u.String(), nil
+}
+
+// Hostname returns the lowercase ASCII (punycode) form of host, so different
+// spellings of a domain (such as fullwidth letters) compare equal. A trailing
+// dot is dropped and hosts that can't be mapped are only lowercased.
+func Hostname(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+
+	asciiHost, err := idnaProfile.ToASCII(host)
+	if err != nil {
+		return host
+	}
+
+	return strings.TrimSuffix(asciiHost, ".")
+}
+
+func normalizeHost(u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	if host != "" && net.ParseIP(host) == nil {
+		asciiHost, err := idnaProfile.ToASCII(host)
+		if err != nil {
+			return err
+		}
+		host = asciiHost
+	}
+
+	if defaultPorts[u.Scheme] == port {
+		port = ""
+	}
+
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// ipv6 literals must keep their brackets
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	return nil
+}
+
+func normalizePath(u *url.URL) error {
+	escaped := resolveDotSegments(u.EscapedPath())
+	if escaped == "" && u.Host != "" {
+		escaped = "/"
+	}
+
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return err
+	}
+
+	u.Path = path
+	u.RawPath = escaped
+	return nil
+}
+
+// resolveDotSegments implements the "remove_dot_segments" algorithm from
+// RFC 3986, section 5.2.4.
+func resolveDotSegments(path string) string {
+	if path == "" {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	resolved := make([]string, 0, len(segments))
+
+	for i, segment := range segments {
+		isLast := i == len(segments)-1
+
+		switch segment {
+		case ".":
+			if isLast {
+				resolved = append(resolved, "")
+			}
+		case "..":
+			if len(resolved) > 1 {
+				resolved = resolved[:len(resolved)-1]
+			}
+			if isLast {
+				resolved = append(resolved, "")
+			}
+		default:
+			resolved = append(resolved, segment)
+		}
+	}
+
+	return strings.Join(resolved, "/")
+}
+
+func normalizeQuery(rawQuery string, cfg *config.NormalizeConfig) string {
+	if rawQuery == "" || (!cfg.SortQuery && !cfg.StripTrackingParams) {
+		return rawQuery
+	}
+
+	type param struct {
+		key string
+		raw string
+	}
+
+	var params []param
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+
+		key, _, _ := strings.Cut(raw, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if cfg.StripTrackingParams && isTrackingParam(key) {
+			continue
+		}
+
+		params = append(params, param{key, raw})
+	}
+
+	if cfg.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			return params[i].key < params[j].key
+		})
+	}
+
+	raws := make([]string, len(params))
+	for i, p := range params {
+		raws[i] = p.raw
+	}
+
+	return strings.Join(raws, "&")
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+
+	if trackingParams[key] {
+		return true
+	}
+
+	for _, prefix := range trackingParamPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
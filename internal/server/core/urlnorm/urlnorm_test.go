@@ -0,0 +1,88 @@
+package urlnorm_test
+
+import (
+	"testing"
+
+	"github.com/pauloo27/shurl/internal/config"
+	"github.com/pauloo27/shurl/internal/server/core/urlnorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeDisabled(t *testing.T) {
+	raw := "HTTP://Example.com:80/a/../b"
+
+	normalized, err := urlnorm.Normalize(raw, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, normalized)
+
+	normalized, err = urlnorm.Normalize(raw, &config.NormalizeConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, raw, normalized)
+}
+
+func TestNormalize(t *testing.T) {
+	cfg := &config.NormalizeConfig{Enabled: true}
+
+	cases := map[string]string{
+		"HTTP://Example.com:80/a/../b":     "http://example.com/b",
+		"https://example.com:443":          "https://example.com/",
+		"https://example.com:8443/./a/":    "https://example.com:8443/a/",
+		"http://example.com/a/b/..":        "http://example.com/a/",
+		"http://example.com/../../a":       "http://example.com/a",
+		"http://bücher.example/":           "http://xn--bcher-kva.example/",
+		"http://[::1]:80/":                 "http://[::1]/",
+		"http://example.com/a%2Fb/../c":    "http://example.com/c",
+		"http://example.com/?b=1&a=2#frag": "http://example.com/?b=1&a=2#frag",
+		"MAILTO:someone@example.com":       "mailto:someone@example.com",
+		"MyApp://Open/Item/../x":           "myapp://Open/Item/../x",
+	}
+
+	for raw, expected := range cases {
+		t.Run(raw, func(t *testing.T) {
+			normalized, err := urlnorm.Normalize(raw, cfg)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, normalized)
+		})
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	raw := "http://example.com/?b=1&utm_source=x&a=2&fbclid=y&a=1"
+
+	t.Run("Sort query", func(t *testing.T) {
+		cfg := &config.NormalizeConfig{Enabled: true, SortQuery: true}
+		normalized, err := urlnorm.Normalize(raw, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://example.com/?a=2&a=1&b=1&fbclid=y&utm_source=x", normalized)
+	})
+
+	t.Run("Strip tracking params", func(t *testing.T) {
+		cfg := &config.NormalizeConfig{Enabled: true, StripTrackingParams: true}
+		normalized, err := urlnorm.Normalize(raw, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://example.com/?b=1&a=2&a=1", normalized)
+	})
+
+	t.Run("Only tracking params", func(t *testing.T) {
+		cfg := &config.NormalizeConfig{Enabled: true, StripTrackingParams: true}
+		normalized, err := urlnorm.Normalize("http://example.com/?utm_medium=email", cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://example.com/", normalized)
+	})
+}
+
+func TestHostname(t *testing.T) {
+	cases := map[string]string{
+		"Example.COM.":   "example.com",
+		"ｇｒａｂｉｆｙ.link":   "grabify.link",
+		"bücher.example": "xn--bcher-kva.example",
+		"::1":            "::1",
+		"":               "",
+	}
+
+	for raw, expected := range cases {
+		t.Run(raw, func(t *testing.T) {
+			assert.Equal(t, expected, urlnorm.Hostname(raw))
+		})
+	}
+}
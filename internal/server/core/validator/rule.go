@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pauloo27/shurl/internal/server/core/targeting"
+)
+
+var (
+	// a simplified BCP 47 language tag, such as "pt" or "pt-BR"
+	languageTagRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+)
+
+func validatePlatform(fl validator.FieldLevel) bool {
+	return targeting.IsPlatform(fl.Field().String())
+}
+
+func validateLanguageTag(fl validator.FieldLevel) bool {
+	return languageTagRegex.MatchString(fl.Field().String())
+}
@@ -0,0 +1,34 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/pauloo27/shurl/internal/models"
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRule(t *testing.T) {
+	valid := []models.Rule{
+		{URL: "https://apps.apple.com/app/id1", Platforms: []string{"ios"}},
+		{URL: "https://example.com/pt", Languages: []string{"pt", "pt-BR"}},
+		{URL: "https://example.com/social", Referrers: []string{"facebook.com", "t.co"}},
+		{URL: "https://example.com/", Platforms: []string{"desktop"}, Languages: []string{"en"}},
+	}
+
+	invalid := map[string]models.Rule{
+		"no condition":     {URL: "https://example.com/"},
+		"unknown platform": {URL: "https://example.com/", Platforms: []string{"symbian"}},
+		"invalid language": {URL: "https://example.com/", Languages: []string{"portuguese"}},
+		"invalid referrer": {URL: "https://example.com/", Referrers: []string{"https://facebook.com"}},
+		"invalid url":      {URL: "javascript:alert(1)", Platforms: []string{"ios"}},
+	}
+
+	for _, rule := range valid {
+		assert.Empty(t, validator.Validate(rule), rule)
+	}
+
+	for name, rule := range invalid {
+		assert.NotEmpty(t, validator.Validate(rule), name)
+	}
+}
@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	// schemes that can never be used as a destination, no matter the config
+	deniedSchemes = map[string]bool{
+		"javascript": true,
+		"vbscript":   true,
+		"data":       true,
+		"file":       true,
+		"blob":       true,
+		"filesystem": true,
+	}
+
+	defaultAllowedSchemes = []string{"http", "https"}
+)
+
+// SchemeAllowed tells if the scheme of rawURL is in the allowed list (http
+// and https when empty) and not in the hard denylist.
+func SchemeAllowed(rawURL string, allowed []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if deniedSchemes[scheme] {
+		return false
+	}
+
+	if len(allowed) == 0 {
+		allowed = defaultAllowedSchemes
+	}
+
+	for _, allowedScheme := range allowed {
+		if strings.EqualFold(scheme, allowedScheme) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SchemeDenied tells if the scheme of rawURL can never be used as a
+// destination.
+func SchemeDenied(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	return deniedSchemes[strings.ToLower(u.Scheme)]
+}
+
+// validateLinkURL is like http_url, but also accepts any other scheme that is
+// not denied, such as mailto:, tel: or app deep links (myapp://).
+func validateLinkURL(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || u.Scheme == "" {
+		return false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if deniedSchemes[scheme] {
+		return false
+	}
+
+	if scheme == "http" || scheme == "https" {
+		return u.Host != ""
+	}
+
+	return u.Opaque != "" || u.Host != "" || u.Path != ""
+}
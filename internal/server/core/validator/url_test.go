@@ -0,0 +1,62 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+type SampleLink struct {
+	URL string `json:"url" validate:"required,link_url"`
+}
+
+func TestLinkURL(t *testing.T) {
+	valid := []string{
+		"http://example.com",
+		"https://example.com/path?q=1",
+		"mailto:someone@example.com",
+		"tel:+5511999999999",
+		"myapp://open/item/1",
+	}
+
+	invalid := []string{
+		"example.com",
+		"http://",
+		"javascript:alert(1)",
+		"JavaScript:alert(1)",
+		"data:text/html,<script>alert(1)</script>",
+		"file:///etc/passwd",
+		"vbscript:msgbox",
+	}
+
+	for _, url := range valid {
+		assert.Empty(t, validator.Validate(SampleLink{URL: url}), url)
+	}
+
+	for _, url := range invalid {
+		errs := validator.Validate(SampleLink{URL: url})
+		if assert.Len(t, errs, 1, url) {
+			assert.Equal(t, "link_url", errs[0].Error)
+		}
+	}
+}
+
+func TestSchemeAllowed(t *testing.T) {
+	assert.True(t, validator.SchemeAllowed("https://example.com", nil))
+	assert.False(t, validator.SchemeAllowed("mailto:someone@example.com", nil))
+
+	allowed := []string{"https", "MailTo", "javascript"}
+	assert.True(t, validator.SchemeAllowed("mailto:someone@example.com", allowed))
+	assert.False(t, validator.SchemeAllowed("http://example.com", allowed))
+
+	// the hard denylist always wins
+	assert.False(t, validator.SchemeAllowed("javascript:alert(1)", allowed))
+}
+
+func TestSchemeDenied(t *testing.T) {
+	assert.False(t, validator.SchemeDenied("https://example.com"))
+	assert.False(t, validator.SchemeDenied("myapp://open"))
+	assert.True(t, validator.SchemeDenied("JavaScript:alert(1)"))
+	assert.True(t, validator.SchemeDenied("data:text/html,hi"))
+}
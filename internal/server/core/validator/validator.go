@@ -26,6 +26,16 @@ func init() {
 		}
 		return name
 	})
+
+	if err := validate.RegisterValidation("link_url", validateLinkURL); err != nil {
+		panic(err)
+	}
+	if err := validate.RegisterValidation("platform", validatePlatform); err != nil {
+		panic(err)
+	}
+	if err := validate.RegisterValidation("language_tag", validateLanguageTag); err != nil {
+		panic(err)
+	}
 }
 
 func Validate[T any](v T) []*ValidationError {
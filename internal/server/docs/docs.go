@@ -19,69 +19,88 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/healthz": {
+        "/admin/apps": {
             "get": {
-                "description": "Get the health status of the server",
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "List the apps, both the ones in the config and the ones created at runtime. API keys are not returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
+                ],
+                "summary": "List apps",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "Get health status",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/health.HealthStatus"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.App"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
                         "schema": {
-                            "$ref": "#/definitions/health.HealthStatus"
+                            "$ref": "#/definitions/api.UnauthorizedError"
                         }
                     }
                 }
-            }
-        },
-        "/links": {
+            },
             "post": {
                 "security": [
                     {
-                        "ApiKeyAuth": []
+                        "AdminKeyAuth": []
                     }
                 ],
-                "description": "Create a link from a slug to the original URL.\nIf no slug is provided, a random one will be generated.\nThe ttl is required. 0 means no expiration, otherwise it's the number of seconds until expiration.\nThe ttl can't be greater than 1 year (31536000 seconds).\nThe API Key may limit the ttl.",
+                "description": "Create an app at runtime, without restarting the server. It's available to every replica right away.\nWhen no API key (or API key hash) is sent, a random one is generated. The API key is only returned here,\nonly its SHA-256 hash is stored.\nApps are enabled by default.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "link"
+                    "admin"
                 ],
-                "summary": "Create a link",
+                "summary": "Create an app",
                 "parameters": [
                     {
-                        "description": "Slug is optional",
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Settings are optional",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/link.CreateLinkBody"
+                            "$ref": "#/definitions/app.CreateAppBody"
                         }
-                    },
-                    {
-                        "type": "string",
-                        "description": "API Key, leave empty for public access (if enabled in the server)",
-                        "name": "X-API-Key",
-                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Link"
+                            "$ref": "#/definitions/models.App"
                         }
                     },
                     "400": {
@@ -91,19 +110,13 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Missing API Key",
+                        "description": "Missing or invalid admin API Key",
                         "schema": {
                             "$ref": "#/definitions/api.UnauthorizedError"
                         }
                     },
-                    "403": {
-                        "description": "Invalid API Key",
-                        "schema": {
-                            "$ref": "#/definitions/api.ForbiddenError"
-                        }
-                    },
                     "409": {
-                        "description": "Duplicated link",
+                        "description": "Name or API key already used",
                         "schema": {
                             "$ref": "#/definitions/api.ConflictError"
                         }
@@ -123,28 +136,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/{slug}": {
-            "get": {
-                "description": "Redirect from domain/slug to the original URL",
+        "/admin/apps/{name}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Delete an app created at runtime, its API key stops working right away. Its links are kept.\nApps defined in the config can't be deleted.",
                 "tags": [
-                    "link"
+                    "admin"
                 ],
-                "summary": "Redirect to the original URL",
+                "summary": "Delete an app",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Slug to redirect from",
-                        "name": "slug",
+                        "description": "Name of the app",
+                        "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "307": {
-                        "description": "Temporary Redirect"
+                    "204": {
+                        "description": "Deleted"
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "App defined in the config",
+                        "schema": {
+                            "$ref": "#/definitions/api.ForbiddenError"
+                        }
                     },
                     "404": {
-                        "description": "Link not found",
+                        "description": "App not found",
                         "schema": {
                             "$ref": "#/definitions/api.NotFoundError"
                         }
@@ -156,192 +193,1722 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "api.BadRequestError": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    },
-                    "example": {
-                        "message": "Error message"
+            },
+            "patch": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
                     }
-                },
-                "error": {
-                    "type": "string",
-                    "example": "BAD_REQUEST"
-                }
-            }
-        },
-        "api.ConflictError": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+                ],
+                "description": "Enable, disable or change the limits of an app created at runtime, the missing settings are left untouched.\nApps defined in the config can't be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update an app",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the app",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     },
-                    "example": {
-                        "message": "Error message"
-                    }
-                },
-                "error": {
-                    "type": "string",
-                    "example": "CONFLICT"
-                }
-            }
-        },
-        "api.ForbiddenError": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
                     },
-                    "example": {
-                        "message": "Error message"
+                    {
+                        "description": "Settings to change",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app.AppSettingsBody"
+                        }
                     }
-                },
-                "error": {
-                    "type": "string",
-                    "example": "FORBIDDEN"
-                }
-            }
-        },
-        "api.InternalServerError": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.App"
+                        }
                     },
-                    "example": {
-                        "message": "Error message"
-                    }
-                },
-                "error": {
-                    "type": "string",
-                    "example": "INTERNAL_SERVER_ERROR"
-                }
-            }
-        },
-        "api.NotFoundError": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BadRequestError"
+                        }
                     },
-                    "example": {
-                        "message": "Error message"
-                    }
-                },
-                "error": {
-                    "type": "string",
-                    "example": "NOT_FOUND"
-                }
-            }
-        },
-        "api.UnauthorizedError": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
                     },
-                    "example": {
-                        "message": "Error message"
+                    "403": {
+                        "description": "App defined in the config",
+                        "schema": {
+                            "$ref": "#/definitions/api.ForbiddenError"
+                        }
+                    },
+                    "404": {
+                        "description": "App not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "422": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
                     }
-                },
-                "error": {
-                    "type": "string",
-                    "example": "UNAUTHORIZED"
                 }
             }
         },
-        "api.ValidationError": {
-            "type": "object",
-            "properties": {
-                "detail": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.ValidationErrorDetail"
+        "/admin/apps/{name}/keys": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
                     }
-                },
-                "error": {
-                    "type": "string",
-                    "example": "VALIDATION_ERROR"
-                }
-            }
-        },
-        "api.ValidationErrorDetail": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
+                ],
+                "description": "Add another API key to an app created at runtime, the other keys keep working. That's how keys are rotated:\nadd the new key, move the clients to it and then expire or remove the old one.\nWhen no API key (or API key hash) is sent, a random one is generated. The API key is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Add an API key to an app",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the app",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "The key never expires without not_after",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app.AddKeyBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.App"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BadRequestError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "App defined in the config",
+                        "schema": {
+                            "$ref": "#/definitions/api.ForbiddenError"
+                        }
+                    },
+                    "404": {
+                        "description": "App not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "409": {
+                        "description": "Label or API key already used",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConflictError"
+                        }
+                    },
+                    "422": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/apps/{name}/keys/{label}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Remove an API key of an app created at runtime, it stops working right away. The last key of the app can't be\nremoved, add the new one first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Remove an API key from an app",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the app",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label of the key",
+                        "name": "label",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.App"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BadRequestError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "App defined in the config",
+                        "schema": {
+                            "$ref": "#/definitions/api.ForbiddenError"
+                        }
+                    },
+                    "404": {
+                        "description": "App or key not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "409": {
+                        "description": "Last key of the app",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConflictError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Set when an API key of an app created at runtime stops working, so the old key keeps working for a while after a\nrotation. Without not_after the key never expires.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Change when an API key expires",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the app",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label of the key",
+                        "name": "label",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "When the key expires",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app.UpdateKeyBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.App"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BadRequestError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "App defined in the config",
+                        "schema": {
+                            "$ref": "#/definitions/api.ForbiddenError"
+                        }
+                    },
+                    "404": {
+                        "description": "App or key not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "List the links quarantined by abuse reports, oldest first, with their latest reports.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List quarantined links",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.QuarantinedLink"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine/{domain}/{slug}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Delete a quarantined link along with its stats and reports, it answers 410 Gone from now on. The slug can't be\nused again for a while, so it isn't taken over by the same abuse.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a quarantined link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain of the link",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slug of the link",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted"
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found or not quarantined",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine/{domain}/{slug}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Take a link out of quarantine, so it redirects again. Its reports are cleared.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Restore a quarantined link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain of the link",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Slug of the link",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API Key",
+                        "name": "X-Admin-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Restored"
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found or not quarantined",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Get the health status of the server",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Get health status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.HealthStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/health.HealthStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/links": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a link from a slug to the original URL.\nIf no slug is provided, a random one will be generated.\nInstead of the original URL, up to 10 destinations can be set to split the traffic between them, proportionally to their weights (1 by default).\nThe sticky option sends the same visitor to the same destination, using a cookie or their IP.\nRules send matching requests elsewhere, by platform (ios, android, windows, macos, linux, other, mobile or desktop),\npreferred language (pt matches pt-BR) and referrer domain (subdomains included). The first matching rule wins,\nthe original URL (or destinations) is used when none match.\nThe ttl is required, unless expires_at is set. 0 means no expiration, otherwise it's the number of seconds until expiration.\nThe expires_at is the RFC 3339 time when the link expires, it can be used instead of the ttl.\nThe not_before is the RFC 3339 time when the link starts redirecting, it may be up to 1 year from now.\nThe link can't be active for more than 1 year (31536000 seconds).\nThe idle_ttl makes the link expire that many seconds after it was last used instead, it can be combined with the ttl or expires_at to set an absolute expiration.\nWithout an absolute expiration, idle links are still bounded by the API Key max duration.\nThe API Key may limit for how long the link is active.\nOnce expired, the link answers 410 Gone for a grace period set by the API Key, and the slug can't be used again in the meanwhile.\nThe fallback_url is where the expired link redirects to in the grace period, instead of 410 Gone. The API Key may have a default one.\nThe original URL scheme must be allowed by the API Key, http and https are allowed by default.\nThe original URL may be normalized before being stored, depending on the API Key.\nThe redirect type is optional, it's the status code used to redirect (301, 302, 303, 307 or 308).\nWhen missing, the API Key default is used (307 if not set). The API Key may limit the redirect types.\nWhen forward_query is set, the redirect request query is merged into the original URL query.\nThe query_conflict chooses what happens to parameters in both: keep (default) the original ones, override them or append both.\nWhen forward_path is set, paths after the slug (/slug/extra/path) are appended to the original URL path.\nThe utm parameters are added to the destination when redirecting, the API Key may have defaults for the missing ones.\nParameters already in the destination are never overwritten.\nWhen a password is set, visitors must enter it before being redirected.\nWhen max_clicks is set, the link stops working (410 Gone) after being used that many times.\nThe domain is optional, when provided it's used instead of the request host and must be allowed by the API Key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "link"
+                ],
+                "summary": "Create a link",
+                "parameters": [
+                    {
+                        "description": "Slug is optional",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.CreateLinkBody"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "API Key, leave empty for public access (if enabled in the server or domain)",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Link"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BadRequestError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid API Key, blacklisted slug, domain not allowed or blocked destination",
+                        "schema": {
+                            "$ref": "#/definitions/api.ForbiddenError"
+                        }
+                    },
+                    "409": {
+                        "description": "Duplicated link",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConflictError"
+                        }
+                    },
+                    "421": {
+                        "description": "Unknown domain",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnknownDomainError"
+                        }
+                    },
+                    "422": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/links/{slug}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get how many times a link redirected, and to which of its destinations.\nOnly the API Key that created the link can see its stats.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "link"
+                ],
+                "summary": "Get link stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug of the link",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Domain of the link, the request host when empty",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "API Key that created the link",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API Key",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "421": {
+                        "description": "Unknown domain",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnknownDomainError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports": {
+            "post": {
+                "description": "Report a link as phishing, malware, spam or other abuse.\nOnce enough different visitors report it, the link is quarantined: it shows a warning instead of redirecting until an admin reviews it.\nEach IP can only send a few reports per hour.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "link"
+                ],
+                "summary": "Report an abusive link",
+                "parameters": [
+                    {
+                        "description": "Domain is optional, the request host when empty",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/link.ReportLinkBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BadRequestError"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "421": {
+                        "description": "Unknown domain",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnknownDomainError"
+                        }
+                    },
+                    "422": {
+                        "description": "Validation error",
+                        "schema": {
+                            "$ref": "#/definitions/api.ValidationError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many reports",
+                        "schema": {
+                            "$ref": "#/definitions/api.TooManyRequestsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        },
+        "/{slug}": {
+            "get": {
+                "description": "Redirect from domain/slug to the original URL, using the link redirect type.\nTemporary redirects are not cached, permanent ones are cached until the link expires.\nUTM parameters of the link (or the API Key defaults) are added to the destination, unless already present.\nAdding a + to the slug (/{slug}+) or the preview=1 query shows a preview of the link instead.\nPaths after the slug (/{slug}/extra/path) are only accepted by links that forward paths.\nLinks with multiple destinations pick one per request, by weight or sticky to the visitor.\nLinks with rules send the requests matching them (by platform, language and referrer) to the rule destination.\nBrowsers are shown an interstitial page before leaving, for links of some API Keys or suspicious destinations.\nEach use of idle links pushes their expiration back.\nRecently expired links answer 410 Gone, or redirect to their fallback URL.\nLinks with a not_before in the future are not active yet, the domain may redirect them somewhere else.\nLinks limited by clicks answer 410 Gone once all the clicks are used, previews are not counted.\nBrowsers get HTML error pages (the domain may customize them) instead of JSON errors.\nLinks quarantined by abuse reports show a warning page (403) instead of redirecting.\nPassword protected links ask for the password first (an HTML form for browsers), see the POST route.",
+                "tags": [
+                    "link"
+                ],
+                "summary": "Redirect to the original URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug to redirect from",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link preview, HTML for browsers. Also the interstitial page",
+                        "schema": {
+                            "$ref": "#/definitions/models.LinkPreview"
+                        }
+                    },
+                    "301": {
+                        "description": "Moved permanently"
+                    },
+                    "302": {
+                        "description": "Found, also used for missing, expired or inactive links when the domain or API Key has a fallback"
+                    },
+                    "303": {
+                        "description": "See other"
+                    },
+                    "307": {
+                        "description": "Temporary redirect"
+                    },
+                    "308": {
+                        "description": "Permanent redirect"
+                    },
+                    "401": {
+                        "description": "Password protected link, HTML form for browsers",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "403": {
+                        "description": "Link not active yet, or quarantined (QUARANTINED error)",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotActiveError"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "410": {
+                        "description": "Link has no clicks left or expired recently",
+                        "schema": {
+                            "$ref": "#/definitions/api.GoneError"
+                        }
+                    },
+                    "421": {
+                        "description": "Unknown domain",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnknownDomainError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Check the password of a protected link, the form shown when redirecting posts to it.\nWhen the password is right, a cookie remembering it is set and the client is sent back to the link.\nToo many wrong passwords from the same IP are rate limited.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "tags": [
+                    "link"
+                ],
+                "summary": "Unlock a password protected link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug of the link",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of the link",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "See other, back to the link"
+                    },
+                    "401": {
+                        "description": "Wrong password",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnauthorizedError"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotFoundError"
+                        }
+                    },
+                    "421": {
+                        "description": "Unknown domain",
+                        "schema": {
+                            "$ref": "#/definitions/api.UnknownDomainError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong passwords",
+                        "schema": {
+                            "$ref": "#/definitions/api.TooManyRequestsError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/api.InternalServerError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BadRequestError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "BAD_REQUEST"
+                }
+            }
+        },
+        "api.ConflictError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "CONFLICT"
+                }
+            }
+        },
+        "api.ForbiddenError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "FORBIDDEN"
+                }
+            }
+        },
+        "api.GoneError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "GONE"
+                }
+            }
+        },
+        "api.InternalServerError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "INTERNAL_SERVER_ERROR"
+                }
+            }
+        },
+        "api.NotActiveError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message",
+                        "not_before": "2025-01-01T00:00:00Z"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "NOT_ACTIVE"
+                }
+            }
+        },
+        "api.NotFoundError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "NOT_FOUND"
+                }
+            }
+        },
+        "api.TooManyRequestsError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "TOO_MANY_REQUESTS"
+                }
+            }
+        },
+        "api.UnauthorizedError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "UNAUTHORIZED"
+                }
+            }
+        },
+        "api.UnknownDomainError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "message": "Error message"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "UNKNOWN_DOMAIN"
+                }
+            }
+        },
+        "api.ValidationError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ValidationErrorDetail"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "VALIDATION_ERROR"
+                }
+            }
+        },
+        "api.ValidationErrorDetail": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
                     "example": "required"
                 },
-                "field": {
+                "field": {
+                    "type": "string",
+                    "example": "username"
+                }
+            }
+        },
+        "app.AddKeyBody": {
+            "type": "object",
+            "required": [
+                "label"
+            ],
+            "properties": {
+                "api_key": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 16
+                },
+                "api_key_hash": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "not_after": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.AppSettingsBody": {
+            "type": "object",
+            "required": [
+                "allowed_schemes",
+                "domains"
+            ],
+            "properties": {
+                "allowed_schemes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "default_redirect_type": {
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        301,
+                        302,
+                        303,
+                        307,
+                        308
+                    ]
+                },
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "max_duration_sec": {
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 0
+                },
+                "min_duration_sec": {
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 0
+                },
+                "redirect_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "app.CreateAppBody": {
+            "type": "object",
+            "required": [
+                "allowed_schemes",
+                "domains",
+                "name"
+            ],
+            "properties": {
+                "allowed_schemes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "api_key": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 16
+                },
+                "api_key_hash": {
+                    "type": "string"
+                },
+                "default_redirect_type": {
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        301,
+                        302,
+                        303,
+                        307,
+                        308
+                    ]
+                },
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "max_duration_sec": {
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 0
+                },
+                "min_duration_sec": {
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "redirect_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "app.UpdateKeyBody": {
+            "type": "object",
+            "properties": {
+                "not_after": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.HealthStatus": {
+            "type": "object",
+            "properties": {
+                "valkey": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "link.CreateLinkBody": {
+            "type": "object",
+            "properties": {
+                "destinations": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/models.Destination"
+                    }
+                },
+                "domain": {
+                    "type": "string",
+                    "maxLength": 253
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "fallback_url": {
+                    "type": "string"
+                },
+                "forward_path": {
+                    "type": "boolean"
+                },
+                "forward_query": {
+                    "type": "boolean"
+                },
+                "idle_ttl": {
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 1
+                },
+                "max_clicks": {
+                    "type": "integer",
+                    "maximum": 1000000,
+                    "minimum": 1
+                },
+                "not_before": {
+                    "type": "string"
+                },
+                "original_url": {
+                    "type": "string"
+                },
+                "password": {
                     "type": "string",
-                    "example": "username"
+                    "maxLength": 128,
+                    "minLength": 4
+                },
+                "query_conflict": {
+                    "type": "string",
+                    "enum": [
+                        "keep",
+                        "override",
+                        "append"
+                    ]
+                },
+                "redirect_type": {
+                    "type": "integer",
+                    "enum": [
+                        301,
+                        302,
+                        303,
+                        307,
+                        308
+                    ]
+                },
+                "rules": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/models.Rule"
+                    }
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "minLength": 3
+                },
+                "sticky": {
+                    "type": "string",
+                    "enum": [
+                        "cookie",
+                        "ip"
+                    ]
+                },
+                "ttl": {
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 0
+                },
+                "utm": {
+                    "$ref": "#/definitions/models.UTM"
                 }
             }
         },
-        "health.HealthStatus": {
+        "link.ReportLinkBody": {
             "type": "object",
+            "required": [
+                "reason",
+                "slug"
+            ],
             "properties": {
-                "valkey": {
+                "details": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "domain": {
+                    "type": "string",
+                    "maxLength": 253
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "phishing",
+                        "malware",
+                        "spam",
+                        "other"
+                    ]
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 20
+                }
+            }
+        },
+        "models.App": {
+            "type": "object",
+            "properties": {
+                "allowed_schemes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "api_key": {
+                    "description": "only returned when the app is created, unless it was created from a\nhash",
+                    "type": "string"
+                },
+                "default_redirect_type": {
+                    "type": "integer"
+                },
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "from_config": {
+                    "type": "boolean"
+                },
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AppKey"
+                    }
+                },
+                "max_duration_sec": {
+                    "type": "integer"
+                },
+                "min_duration_sec": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "redirect_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.AppKey": {
+            "type": "object",
+            "properties": {
+                "expired": {
                     "type": "boolean"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "not_after": {
+                    "type": "string"
                 }
             }
         },
-        "link.CreateLinkBody": {
+        "models.Destination": {
             "type": "object",
             "required": [
-                "original_url"
+                "url"
             ],
             "properties": {
+                "url": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                }
+            }
+        },
+        "models.Link": {
+            "type": "object",
+            "properties": {
+                "destinations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Destination"
+                    }
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "fallback_url": {
+                    "type": "string"
+                },
+                "forward_path": {
+                    "type": "boolean"
+                },
+                "forward_query": {
+                    "type": "boolean"
+                },
+                "idle_ttl": {
+                    "type": "integer"
+                },
+                "max_clicks": {
+                    "type": "integer"
+                },
+                "max_expires_at": {
+                    "type": "string"
+                },
+                "not_before": {
+                    "type": "string"
+                },
                 "original_url": {
                     "type": "string"
                 },
+                "protected": {
+                    "type": "boolean"
+                },
+                "query_conflict": {
+                    "type": "string"
+                },
+                "redirect_type": {
+                    "type": "integer"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Rule"
+                    }
+                },
                 "slug": {
-                    "type": "string",
-                    "maxLength": 20,
-                    "minLength": 3
+                    "type": "string"
+                },
+                "sticky": {
+                    "type": "string"
                 },
                 "ttl": {
-                    "type": "integer",
-                    "maximum": 31536000,
-                    "minimum": 0
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "utm": {
+                    "$ref": "#/definitions/models.UTM"
                 }
             }
         },
-        "models.Link": {
+        "models.LinkPreview": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "destination_domain": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "not_before": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LinkStats": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VariantStats"
+                    }
+                }
+            }
+        },
+        "models.QuarantinedLink": {
             "type": "object",
             "properties": {
+                "api_key_label": {
+                    "type": "string"
+                },
+                "app": {
+                    "type": "string"
+                },
                 "domain": {
                     "type": "string"
                 },
                 "original_url": {
                     "type": "string"
                 },
+                "quarantined_at": {
+                    "type": "string"
+                },
+                "reporters": {
+                    "type": "integer"
+                },
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Report"
+                    }
+                },
                 "slug": {
                     "type": "string"
+                }
+            }
+        },
+        "models.Report": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
                 },
-                "ttl": {
+                "reason": {
+                    "type": "string"
+                },
+                "reported_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Rule": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "languages": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "platforms": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "referrers": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UTM": {
+            "type": "object",
+            "properties": {
+                "campaign": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "content": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "medium": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "source": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "term": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "models.VariantStats": {
+            "type": "object",
+            "properties": {
+                "clicks": {
                     "type": "integer"
                 },
                 "url": {
                     "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
                 }
             }
         }
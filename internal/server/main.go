@@ -7,6 +7,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/pauloo27/shurl/internal/providers"
+	"github.com/pauloo27/shurl/internal/server/core/forwarded"
 )
 
 // @title			Shurl API
@@ -20,7 +21,17 @@ func StartServer(providers *providers.Providers) error {
 
 	bindAddr := fmt.Sprintf(":%d", providers.Config.HTTP.Port)
 
-	route(providers, e)
+	trustedProxies, err := forwarded.ParseTrustedProxies(providers.Config.HTTP.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	e.IPExtractor = forwarded.IPExtractor(trustedProxies)
+	e.Pre(forwarded.Middleware(trustedProxies))
+
+	if err := route(providers, e); err != nil {
+		return err
+	}
 
 	server := &http.Server{
 		ReadTimeout:  10 * time.Second,
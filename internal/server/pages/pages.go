@@ -0,0 +1,111 @@
+package pages
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/server/core/validator"
+)
+
+//go:embed templates assets
+var files embed.FS
+
+var (
+	pages = make(map[string]*template.Template)
+	funcs template.FuncMap
+)
+
+// ErrorPage is the data of the error page, and of the custom ones replacing
+// it.
+type ErrorPage struct {
+	Status  int
+	Title   string
+	Message string
+}
+
+func init() {
+	style, err := files.ReadFile("assets/style.css")
+	if err != nil {
+		panic(err)
+	}
+
+	funcs = template.FuncMap{
+		"style": func() template.CSS {
+			/* #nosec G203 */
+			return template.CSS(style)
+		},
+		"formatTime": func(t time.Time) string {
+			return t.UTC().Format("2006-01-02 15:04 MST")
+		},
+		// html/template only trusts http, https and mailto links, but
+		// destinations may be deep links (myapp://) or tel: as well
+		"destinationHref": func(destination string) template.URL {
+			if validator.SchemeDenied(destination) {
+				return template.URL("#")
+			}
+			/* #nosec G203 */
+			return template.URL(destination)
+		},
+	}
+
+	entries, err := files.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".html")
+		if name == "layout" {
+			continue
+		}
+
+		pages[name] = template.Must(
+			template.New(name).Funcs(funcs).ParseFS(files, "templates/layout.html", "templates/"+entry.Name()),
+		)
+	}
+}
+
+// AcceptsHTML tells if the client is a browser, API clients get JSON
+// responses instead.
+func AcceptsHTML(req *http.Request) bool {
+	return strings.Contains(req.Header.Get(echo.HeaderAccept), echo.MIMETextHTML)
+}
+
+// Render writes the page with the given name (a file in the templates
+// folder, without extension).
+func Render(ctx echo.Context, status int, name string, data any) error {
+	page, found := pages[name]
+	if !found {
+		return echo.NewHTTPError(http.StatusInternalServerError, "page not found: "+name)
+	}
+
+	return render(ctx, status, page, "layout", data)
+}
+
+// ParseCustom loads a page from outside the binary, it's a whole HTML
+// template (the layout is not used) with the same functions as the embedded
+// pages.
+func ParseCustom(path string) (*template.Template, error) {
+	return template.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
+}
+
+// RenderCustom writes a page loaded by ParseCustom.
+func RenderCustom(ctx echo.Context, status int, page *template.Template, data any) error {
+	return render(ctx, status, page, page.Name(), data)
+}
+
+func render(ctx echo.Context, status int, page *template.Template, name string, data any) error {
+	// rendered to a buffer first, so errors don't leave a half written page
+	var buf bytes.Buffer
+	if err := page.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+
+	return ctx.HTMLBlob(status, buf.Bytes())
+}
@@ -0,0 +1,79 @@
+package pages_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pauloo27/shurl/internal/server/pages"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptsHTML(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, pages.AcceptsHTML(req))
+
+	req.Header.Set("Accept", "application/json")
+	assert.False(t, pages.AcceptsHTML(req))
+
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	assert.True(t, pages.AcceptsHTML(req))
+}
+
+func TestRenderUnknownPage(t *testing.T) {
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.Error(t, pages.Render(ctx, http.StatusOK, "nope", nil))
+}
+
+func TestRenderEscapesData(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	err := pages.Render(ctx, http.StatusOK, "preview", map[string]any{
+		"URL":         "<script>alert(1)</script>",
+		"Destination": "javascript:alert(1)",
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, rec.Body.String(), "<script>alert(1)</script>")
+	assert.NotContains(t, rec.Body.String(), `href="javascript:alert(1)"`)
+}
+
+func TestRenderDeepLink(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	err := pages.Render(ctx, http.StatusOK, "interstitial", map[string]any{
+		"URL":         "http://localhost/app",
+		"Destination": "myapp://open/item/1",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), `href="myapp://open/item/1"`)
+}
+
+func TestRenderCustom(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gone.html")
+	err := os.WriteFile(path, []byte(`<style>{{ style }}</style><p>{{ .Message }}</p>`), 0o600)
+	assert.NoError(t, err)
+
+	page, err := pages.ParseCustom(path)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	err = pages.RenderCustom(ctx, http.StatusGone, page, pages.ErrorPage{
+		Status:  http.StatusGone,
+		Message: "<b>gone</b>",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusGone, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<p>&lt;b&gt;gone&lt;/b&gt;</p>")
+}
+
+func TestParseCustomMissingFile(t *testing.T) {
+	_, err := pages.ParseCustom(filepath.Join(t.TempDir(), "nope.html"))
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/pauloo27/shurl/internal/providers"
+	"github.com/pauloo27/shurl/internal/server/api/app"
 	"github.com/pauloo27/shurl/internal/server/api/health"
 	"github.com/pauloo27/shurl/internal/server/api/link"
 
@@ -11,14 +12,18 @@ import (
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
-func route(providers *providers.Providers, e *echo.Echo) {
+func route(providers *providers.Providers, e *echo.Echo) error {
 	e.GET("/", func(c echo.Context) error {
 		return c.Redirect(301, "/api/v1/swagger/index.html")
 	})
 
 	routeHealth(providers, e)
-	routeLink(providers, e)
+	if err := routeLink(providers, e); err != nil {
+		return err
+	}
+	routeApp(providers, e)
 	routeSwagger(e)
+	return nil
 }
 
 func routeSwagger(g *echo.Echo) {
@@ -30,7 +35,16 @@ func routeHealth(providers *providers.Providers, e *echo.Echo) {
 	c.Route(e)
 }
 
-func routeLink(providers *providers.Providers, e *echo.Echo) {
-	c := link.NewLinkController(providers.Config, providers.Valkey)
+func routeLink(providers *providers.Providers, e *echo.Echo) error {
+	c, err := link.NewLinkController(providers.Config, providers.Valkey, providers.Apps)
+	if err != nil {
+		return err
+	}
+	c.Route(e)
+	return nil
+}
+
+func routeApp(providers *providers.Providers, e *echo.Echo) {
+	c := app.NewAppController(providers.Config, providers.Apps)
 	c.Route(e)
 }